@@ -0,0 +1,99 @@
+package isort
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// An ArchiveEntry is the result of reformatting a single file within an archive.
+type ArchiveEntry struct {
+	Name    string   // Name of the file within the archive
+	Changes *Changes // Changes that would be made to it
+}
+
+// ReformatArchive reformats every .go file in a tar or zip archive read from r, and returns the
+// changes for each, in the order they appear in the archive. Nothing is written back.
+// The format should be one of "tar", "tar.gz" (or "tgz") or "zip".
+func ReformatArchive(r io.Reader, format string, opts Options) ([]ArchiveEntry, error) {
+	switch format {
+	case "tar":
+		return reformatTar(r, opts)
+	case "tar.gz", "tgz":
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gzr.Close()
+		return reformatTar(gzr, opts)
+	case "zip":
+		return reformatZip(r, opts)
+	}
+	return nil, fmt.Errorf("Unknown archive format %s", format)
+}
+
+func reformatTar(r io.Reader, opts Options) ([]ArchiveEntry, error) {
+	entries := []ArchiveEntry{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		} else if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, ".go") {
+			continue
+		}
+		src, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := reformat(hdr.Name, src, opts)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ArchiveEntry{Name: hdr.Name, Changes: changes})
+	}
+}
+
+func reformatZip(r io.Reader, opts Options) ([]ArchiveEntry, error) {
+	// zip needs random access, so we have to read the whole thing first.
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	entries := []ArchiveEntry{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".go") {
+			continue
+		}
+		src, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := reformat(f.Name, src, opts)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ArchiveEntry{Name: f.Name, Changes: changes})
+	}
+	return entries, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,59 @@
+package isort
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReformatZip(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	addZipFile(t, w, "pkg/test1.go", "isort/test_data/test1.go")
+	addZipFile(t, w, "README.md", "isort/test_data/test2.go")
+	addZipFile(t, w, "pkg/test2.go", "isort/test_data/test2.go")
+	require.NoError(t, w.Close())
+
+	entries, err := ReformatArchive(&buf, "zip", Options{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "pkg/test1.go", entries[0].Name)
+	assert.False(t, entries[0].Changes.Needed)
+	assert.Equal(t, "pkg/test2.go", entries[1].Name)
+	assert.True(t, entries[1].Changes.Needed)
+}
+
+func TestReformatTar(t *testing.T) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	b, err := ioutil.ReadFile("isort/test_data/test2.go")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: "test2.go", Mode: 0644, Size: int64(len(b))}))
+	_, err = w.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	entries, err := ReformatArchive(&buf, "tar", Options{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.True(t, entries[0].Changes.Needed)
+}
+
+func TestReformatUnknownArchive(t *testing.T) {
+	_, err := ReformatArchive(&bytes.Buffer{}, "rar", Options{})
+	assert.Error(t, err)
+}
+
+func addZipFile(t *testing.T, w *zip.Writer, name, filename string) {
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	f, err := w.Create(name)
+	require.NoError(t, err)
+	_, err = f.Write(b)
+	require.NoError(t, err)
+}
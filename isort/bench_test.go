@@ -0,0 +1,51 @@
+package isort
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// largeFile returns the source of a file with the given number of imports, in reverse order.
+func largeFile(n int) []byte {
+	var sb strings.Builder
+	sb.WriteString("package core\n\nimport (\n")
+	for i := n - 1; i >= 0; i-- {
+		fmt.Fprintf(&sb, "\t\"github.com/peterebden/generated/pkg%05d\"\n", i)
+		if i%100 == 0 {
+			fmt.Fprintf(&sb, "\t\"github.com/peterebden/local/pkg%05d\"\n", i)
+		}
+	}
+	sb.WriteString(")\n")
+	return []byte(sb.String())
+}
+
+var largeFileOptions = Options{LocalPackage: "github.com/peterebden/local"}
+
+func TestLargeFileAllocations(t *testing.T) {
+	src := largeFile(5000)
+	// Parsing alone needs a certain amount; we shouldn't need much more than that on top.
+	parseAllocs := testing.AllocsPerRun(5, func() {
+		parser.ParseFile(token.NewFileSet(), "large.go", src, parser.ImportsOnly|parser.ParseComments)
+	})
+	allocs := testing.AllocsPerRun(5, func() {
+		reformat("large.go", src, largeFileOptions)
+	})
+	t.Logf("%.0f allocations to parse, %.0f to reformat", parseAllocs, allocs)
+	assert.True(t, allocs-parseAllocs < 50, "%.0f allocations more than parsing", allocs-parseAllocs)
+}
+
+func BenchmarkReformatLargeFile(b *testing.B) {
+	src := largeFile(5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := reformat("large.go", src, largeFileOptions); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package isort
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+)
+
+// A Cache records files that are already known to have correctly sorted imports,
+// so that repeated runs can skip them without reparsing.
+// Entries are keyed by the file's path; each one stores a hash of the file's contents
+// (and the options and version of goisort it was checked with) at the time it was found to
+// need no changes.
+type Cache struct {
+	dir string
+}
+
+// NewCache returns a new cache storing its entries in the given directory, which is
+// created if it doesn't already exist.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Sorted returns true if the given file is known to be sorted with these contents and options.
+func (c *Cache) Sorted(filename string, contents []byte, opts Options) bool {
+	b, err := ioutil.ReadFile(c.entry(filename))
+	return err == nil && string(b) == c.hash(contents, opts)
+}
+
+// MarkSorted records that the given file needs no changes with these contents and options.
+func (c *Cache) MarkSorted(filename string, contents []byte, opts Options) error {
+	return ioutil.WriteFile(c.entry(filename), []byte(c.hash(contents, opts)), 0644)
+}
+
+// entry returns the path of the cache entry for a file.
+func (c *Cache) entry(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	sum := sha256.Sum256([]byte(filename))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// hash returns the hash we store for a file's contents.
+func (c *Cache) hash(contents []byte, opts Options) string {
+	h := sha256.New()
+	key, _ := json.Marshal(cacheKey{
+		Version:                 cacheVersion,
+		Build:                   buildVersion(),
+		LocalPackage:            opts.LocalPackage,
+		ExtraStd:                opts.ExtraStd,
+		GOROOT:                  opts.GOROOT,
+		GoVersion:               opts.GoVersion,
+		CommentGroups:           opts.CommentGroups,
+		RespectGroups:           opts.RespectGroups,
+		SortBy:                  opts.SortBy,
+		AliasedFirst:            opts.AliasedFirst,
+		TieBreak:                opts.TieBreak,
+		HostCaseInsensitive:     opts.HostCaseInsensitive,
+		CaseInsensitive:         opts.CaseInsensitive,
+		BlankImportsLast:        opts.BlankImportsLast,
+		StdlibFamilies:          opts.StdlibFamilies,
+		MaxUngrouped:            opts.MaxUngrouped,
+		WarnIndent:              opts.WarnIndent,
+		WarnUnused:              opts.WarnUnused,
+		Groups:                  opts.Groups,
+		ExtraGroups:             opts.ExtraGroups,
+		GroupRules:              opts.GroupRules,
+		DefaultGroup:            opts.DefaultGroup,
+		GroupSpacing:            opts.GroupSpacing,
+		AlwaysParens:            opts.AlwaysParens,
+		CheckGroupsOnly:         opts.CheckGroupsOnly,
+		FinalNewline:            opts.FinalNewline,
+		IgnoreCommentWhitespace: opts.IgnoreCommentWhitespace,
+		Verify:                  opts.Verify,
+		Gofmt:                   opts.Gofmt,
+	})
+	h.Write(key)
+	h.Write(contents)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheVersion is part of every cache key. It must be changed whenever goisort would format
+// files differently, so that entries written by older versions aren't trusted.
+const cacheVersion = 1
+
+// A cacheKey is everything besides a file's contents that decides whether it needs changes.
+// Every field of Options that affects that must be here.
+type cacheKey struct {
+	Version                 int
+	Build                   string
+	LocalPackage            string
+	ExtraStd                []string
+	GOROOT                  string
+	GoVersion               int
+	CommentGroups           bool
+	RespectGroups           bool
+	SortBy                  SortKey
+	AliasedFirst            bool
+	TieBreak                TieBreak
+	HostCaseInsensitive     bool
+	CaseInsensitive         bool
+	BlankImportsLast        bool
+	StdlibFamilies          bool
+	MaxUngrouped            int
+	WarnIndent              bool
+	WarnUnused              bool
+	Groups                  []string
+	ExtraGroups             []string
+	GroupRules              []GroupRule
+	DefaultGroup            string
+	GroupSpacing            int
+	AlwaysParens            bool
+	CheckGroupsOnly         bool
+	FinalNewline            bool
+	IgnoreCommentWhitespace bool
+	Verify                  bool
+	Gofmt                   bool
+}
+
+// buildVersion returns the version and VCS revision goisort was built from, as far as they're known.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := info.Main.Version
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+			version += " " + setting.Value
+		}
+	}
+	return version
+}
@@ -0,0 +1,64 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cache, err := NewCache(dir)
+	require.NoError(t, err)
+
+	contents := []byte("package core\n\nimport \"fmt\"\n")
+	assert.False(t, cache.Sorted("test1.go", contents, Options{}))
+	assert.NoError(t, cache.MarkSorted("test1.go", contents, Options{}))
+	assert.True(t, cache.Sorted("test1.go", contents, Options{}))
+	// A modified file must be reprocessed.
+	assert.False(t, cache.Sorted("test1.go", append(contents, '\n'), Options{}))
+	// As must a different file with the same contents.
+	assert.False(t, cache.Sorted("test2.go", contents, Options{}))
+}
+
+func TestCacheDifferentOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cache, err := NewCache(dir)
+	require.NoError(t, err)
+
+	contents := []byte("package core\n\nimport \"fmt\"\n")
+	assert.NoError(t, cache.MarkSorted("test1.go", contents, Options{}))
+	assert.False(t, cache.Sorted("test1.go", contents, Options{LocalPackage: "github.com/peterebden/goisort"}))
+}
+
+func TestCacheKeyCoversOptions(t *testing.T) {
+	cache := &Cache{}
+	contents := []byte("package core\n\nimport \"fmt\"\n")
+	base := cache.hash(contents, Options{})
+	typ := reflect.TypeOf(Options{})
+	for i := 0; i < typ.NumField(); i++ {
+		opts := Options{}
+		field := reflect.ValueOf(&opts).Elem().Field(i)
+		switch field.Kind() {
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Int:
+			field.SetInt(1)
+		case reflect.String:
+			field.SetString("x")
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+		case reflect.Func:
+			continue // These can't be compared, so the cache isn't useful along with them.
+		}
+		assert.NotEqual(t, base, cache.hash(contents, opts), "changing %s should change the cache key", typ.Field(i).Name)
+	}
+}
@@ -0,0 +1,228 @@
+package isort
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ConfigFilename is the name of the config file we look for.
+const ConfigFilename = ".goisort.yaml"
+
+// Tokens that can be used in a config file's groups alongside import path prefixes.
+const (
+	configStd     = "std"     // The standard library
+	configDefault = "default" // Anything not matched by another group (except the local package)
+)
+
+// A Config is the contents of a .goisort.yaml file. Only a small subset of YAML is understood;
+// it looks like this:
+//
+//	groups:
+//	  - std
+//	  - golang.org/x/
+//	  - default
+//	  - github.com/myorg/
+//
+// Each import goes into the first group that matches it, where std matches the standard
+// library, default anything that isn't matched by another group, and anything else is an
+// import path prefix. Imports of the local package go in a group at the end unless they're
+// matched by one of these (or local is given explicitly).
+//
+// Alternatively rules can be given in the same pattern=group form as --group-rule, which
+// replace groups if both are given:
+//
+//	rules:
+//	  - std=std
+//	  - github.com/myorg/*=myorg
+//	  - thirdparty=other
+type Config struct {
+	Groups []string
+	Rules  []GroupRule
+}
+
+// DefaultConfig returns the config that's used when there's no file, which gives the same
+// three groups as having no config at all.
+func DefaultConfig() *Config {
+	return &Config{Groups: []string{configStd, configDefault, LocalGroup}}
+}
+
+// FindConfig finds the .goisort.yaml file that applies to the given directory, looking in it
+// and each parent up to the repo root (the first one containing .git), and parses it.
+// It returns the default config if there isn't one.
+func FindConfig(dir string) (*Config, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfigFinder().config(abs)
+}
+
+// A ConfigFinder finds the .goisort.yaml file that applies to each file, in the same way as
+// FindConfig does for its directory. Results are cached per directory so each config file is
+// only read once. It is safe for concurrent use.
+type ConfigFinder struct {
+	mutex sync.Mutex
+	dirs  map[string]configResult
+}
+
+// A configResult is the cached result of looking up the config for a directory.
+type configResult struct {
+	config *Config
+	err    error
+}
+
+// NewConfigFinder returns a new ConfigFinder.
+func NewConfigFinder() *ConfigFinder {
+	return &ConfigFinder{dirs: map[string]configResult{}}
+}
+
+// Config returns the config that applies to the given file, or the default config if there isn't one.
+func (f *ConfigFinder) Config(filename string) (*Config, error) {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.config(dir)
+}
+
+// config returns the config for a directory. The mutex must be held if the finder is shared.
+func (f *ConfigFinder) config(dir string) (*Config, error) {
+	if result, present := f.dirs[dir]; present {
+		return result.config, result.err
+	}
+	c, err := ParseConfig(filepath.Join(dir, ConfigFilename))
+	if os.IsNotExist(err) {
+		if _, statErr := os.Stat(filepath.Join(dir, ".git")); statErr == nil || filepath.Dir(dir) == dir {
+			c, err = DefaultConfig(), nil
+		} else {
+			c, err = f.config(filepath.Dir(dir))
+		}
+	}
+	f.dirs[dir] = configResult{config: c, err: err}
+	return c, err
+}
+
+// ParseConfig parses the given config file.
+func ParseConfig(filename string) (*Config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c := &Config{}
+	key := ""
+	// add adds a single value to the list for the current key.
+	add := func(value string) error {
+		if key == "groups" {
+			c.Groups = append(c.Groups, value)
+			return nil
+		}
+		rule, err := ParseGroupRule(value)
+		if err != nil {
+			return err
+		}
+		c.Rules = append(c.Rules, rule)
+		return nil
+	}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := stripYAMLComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		} else if item := strings.TrimSpace(line); strings.HasPrefix(item, "- ") || item == "-" {
+			if key == "" || line[0] != ' ' && line[0] != '\t' && line[0] != '-' {
+				return nil, fmt.Errorf("%s:%d: unexpected list item", filename, n)
+			}
+			value, err := yamlScalar(strings.TrimPrefix(item, "-"))
+			if err == nil {
+				err = add(value)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", filename, n, err)
+			}
+			continue
+		} else if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("%s:%d: unexpected indentation", filename, n)
+		}
+		idx := strings.IndexByte(line, ':')
+		if idx == -1 {
+			return nil, fmt.Errorf("%s:%d: expected key: value", filename, n)
+		}
+		key = strings.TrimSpace(line[:idx])
+		if key != "groups" && key != "rules" {
+			return nil, fmt.Errorf("%s:%d: unknown setting %s", filename, n, key)
+		}
+		// Also accept the inline form, groups: [std, default]
+		if value := strings.TrimSpace(line[idx+1:]); value != "" {
+			if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+				return nil, fmt.Errorf("%s:%d: %s must be a list", filename, n, key)
+			}
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if strings.TrimSpace(item) == "" {
+					continue
+				}
+				value, err := yamlScalar(item)
+				if err == nil {
+					err = add(value)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %s", filename, n, err)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, group := range c.Groups {
+		if group == "" {
+			return nil, fmt.Errorf("%s: groups can't be empty", filename)
+		}
+	}
+	return c, nil
+}
+
+// stripYAMLComment removes a comment from a line of YAML. It doesn't handle # within quotes,
+// but import paths can't contain them anyway.
+func stripYAMLComment(line string) string {
+	if strings.HasPrefix(line, "#") {
+		return ""
+	} else if idx := strings.Index(line, " #"); idx != -1 {
+		return line[:idx]
+	}
+	return strings.TrimRight(line, " \t\r")
+}
+
+// yamlScalar returns the value of a (possibly quoted) scalar.
+func yamlScalar(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, `"`) {
+		return strconv.Unquote(s)
+	} else if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) > 1 {
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	return s, nil
+}
+
+// GroupSpecs returns the groups in this config in the form Options.Groups takes.
+func (c *Config) GroupSpecs() []string {
+	specs := make([]string, len(c.Groups))
+	for i, group := range c.Groups {
+		switch group {
+		case configStd:
+			specs[i] = StdGroup
+		case configDefault:
+			specs[i] = ThirdPartyGroup
+		default:
+			specs[i] = group
+		}
+	}
+	return specs
+}
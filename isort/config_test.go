@@ -0,0 +1,138 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, ConfigFilename)
+	for contents, expected := range map[string][]string{
+		"# Our import groups\ngroups:\n  - std\n  - golang.org/x/ # standard-ish\n  - default\n  - \"github.com/myorg/\"\n": {"std", "golang.org/x/", "default", "github.com/myorg/"},
+		"groups:\n- std\n- default\n":           {"std", "default"},
+		"groups: [std, 'golang.org/x/', local]": {"std", "golang.org/x/", "local"},
+	} {
+		writeTestFile(t, filename, contents)
+		c, err := ParseConfig(filename)
+		require.NoError(t, err, contents)
+		assert.Equal(t, expected, c.Groups, contents)
+	}
+	for contents, msg := range map[string]string{
+		"sort: name\n":               "unknown setting sort",
+		"groups: std\n":              "groups must be a list",
+		"  - std\n":                  "unexpected list item",
+		"groups:\n  - std\n  oops\n": "unexpected indentation",
+		"groups:\n  - \"\"\n":        "groups can't be empty",
+		"rules:\n  - std\n":          "invalid rule std",
+	} {
+		writeTestFile(t, filename, contents)
+		_, err := ParseConfig(filename)
+		require.Error(t, err, contents)
+		assert.Contains(t, err.Error(), msg, contents)
+	}
+}
+
+func TestParseConfigRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, ConfigFilename)
+	expected := []GroupRule{
+		{Pattern: "std", Group: "std"},
+		{Pattern: "github.com/myorg/*", Group: "myorg"},
+		{Pattern: "a=b", Group: "c"},
+	}
+	for _, contents := range []string{
+		"rules:\n  - std=std\n  - github.com/myorg/*=myorg # ours\n  - \"a=b=c\"\n",
+		"rules: [std=std, 'github.com/myorg/*=myorg', a=b=c]\n",
+	} {
+		writeTestFile(t, filename, contents)
+		c, err := ParseConfig(filename)
+		require.NoError(t, err, contents)
+		assert.Equal(t, expected, c.Rules, contents)
+	}
+
+	// The rules are used in the same way as --group-rule.
+	writeTestFile(t, filename, "groups:\n  - default\nrules:\n  - github.com/myorg/=ours\n  - std=std\n")
+	c, err := ParseConfig(filename)
+	require.NoError(t, err)
+	out, _, err := Format([]byte(`package core
+
+import (
+	"fmt"
+	"github.com/jessevdk/go-flags"
+	"github.com/myorg/lib"
+)
+`), "config.go", Options{Groups: c.GroupSpecs(), GroupRules: c.Rules})
+	require.NoError(t, err)
+	assert.Equal(t, `package core
+
+import (
+	"github.com/myorg/lib"
+
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+)
+`, string(out))
+}
+
+func TestConfigGroups(t *testing.T) {
+	const src = `package core
+
+import (
+	"fmt"
+	"github.com/jessevdk/go-flags"
+	"github.com/myorg/lib"
+	"golang.org/x/mod/semver"
+	"os"
+)
+`
+	c := &Config{Groups: []string{"std", "golang.org/x/", "default", "github.com/myorg/"}}
+	out, _, err := Format([]byte(src), "config.go", Options{Groups: c.GroupSpecs()})
+	require.NoError(t, err)
+	assert.Equal(t, `package core
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/myorg/lib"
+)
+`, string(out))
+
+	// The default config is the same as having none.
+	expected, _, err := Format([]byte(src), "config.go", Options{})
+	require.NoError(t, err)
+	out, _, err = Format([]byte(src), "config.go", Options{Groups: DefaultConfig().GroupSpecs()})
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(out))
+}
+
+func TestFindConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, ConfigFilename), "groups:\n  - std\n  - default\n")
+	c, err := FindConfig(filepath.Join(dir, "a", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"std", "default"}, c.Groups)
+
+	// It stops at the root of the repo.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "repo", ".git"), 0755))
+	c, err = FindConfig(filepath.Join(dir, "repo", "sub"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), c)
+}
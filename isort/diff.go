@@ -0,0 +1,145 @@
+package isort
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of lines of context shown around changes in a diff.
+const diffContext = 3
+
+// Diff returns a unified diff between the given file and what it would be after applying
+// the given changes, or the empty string if there aren't any. It's suitable for git apply or
+// patch -p1.
+func Diff(infile string, changes *Changes) (string, error) {
+	if !changes.Needed {
+		return "", nil
+	}
+	b, err := ioutil.ReadFile(infile)
+	if err != nil {
+		return "", err
+	}
+	out, err := Apply(b, changes)
+	if err != nil {
+		return "", err
+	}
+	return diff(infile, string(b), string(out)), nil
+}
+
+// diff returns a unified diff between two versions of a file.
+// Since we only ever change one region of the file, this always produces a single hunk,
+// which might contain unchanged lines in the middle of it.
+func diff(filename, before, after string) string {
+	// Lines are split in memory rather than scanned, so there's no limit on their length.
+	a := strings.SplitAfter(before, "\n")
+	b := strings.SplitAfter(after, "\n")
+	if a[len(a)-1] == "" {
+		a = a[:len(a)-1]
+	}
+	if b[len(b)-1] == "" {
+		b = b[:len(b)-1]
+	}
+	// Find the lines in common at either end.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	if prefix == len(a) && prefix == len(b) {
+		return ""
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	start := prefix - diffContext
+	if start < 0 {
+		start = 0
+	}
+	endA := len(a) - suffix + diffContext
+	if endA > len(a) {
+		endA = len(a)
+	}
+	endB := len(b) - suffix + diffContext
+	if endB > len(b) {
+		endB = len(b)
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(filename), "/")
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(start, endA-start), hunkRange(start, endB-start))
+	for _, line := range a[start:prefix] {
+		writeDiffLine(&sb, ' ', line)
+	}
+	writeDiffLines(&sb, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, line := range a[len(a)-suffix : endA] {
+		writeDiffLine(&sb, ' ', line)
+	}
+	return sb.String()
+}
+
+// maxDiffCells is the largest table we'll build to find the common lines between two sections.
+// Beyond this we just show one replacing the other, which is still correct but not minimal.
+const maxDiffCells = 1 << 20
+
+// writeDiffLines writes the lines of a diff that turns a into b, keeping as many lines in
+// common between them as possible.
+func writeDiffLines(sb *strings.Builder, a, b []string) {
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			writeDiffLine(sb, '-', line)
+		}
+		for _, line := range b {
+			writeDiffLine(sb, '+', line)
+		}
+		return
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			writeDiffLine(sb, ' ', a[i])
+			i++
+			j++
+		} else if j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]) {
+			writeDiffLine(sb, '-', a[i])
+			i++
+		} else {
+			writeDiffLine(sb, '+', b[j])
+			j++
+		}
+	}
+}
+
+// hunkRange formats the range of lines in a hunk header, given its 0-indexed start and length.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start) // An empty range refers to the line before it.
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// writeDiffLine writes a single line of a diff, which may or may not end in a newline.
+func writeDiffLine(sb *strings.Builder, prefix byte, line string) {
+	sb.WriteByte(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,74 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
+	require.NoError(t, err)
+	d, err := Diff("isort/test_data/test2.go", changes)
+	require.NoError(t, err)
+	assert.Equal(t, `--- a/isort/test_data/test2.go
++++ b/isort/test_data/test2.go
+@@ -2,11 +2,12 @@
+ 
+ import (
+ 	"fmt"
+-	"github.com/jessevdk/go-flags"
+-	"gopkg.in/op/go-logging.v1"
+ 	"os"
+ 	"path"
+ 	"strings"
++
++	"github.com/jessevdk/go-flags"
++	"gopkg.in/op/go-logging.v1"
+ )
+ 
+ var log = logging.MustGetLogger("core")
+`, d)
+}
+
+func TestDiffNotNeeded(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test1.go", Options{})
+	require.NoError(t, err)
+	d, err := Diff("isort/test_data/test1.go", changes)
+	require.NoError(t, err)
+	assert.Empty(t, d)
+}
+
+func TestDiffLongLines(t *testing.T) {
+	// Well beyond bufio.Scanner's default limit of 64KB.
+	comment := "// " + strings.Repeat("very long comment ", 10000)
+	src := "package core\n\nimport (\n\t\"os\" " + comment + "\n\t\"fmt\"\n)\n"
+	f, err := ioutil.TempFile("", "goisort_*.go")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(src)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	filename := f.Name()
+	changes, err := Reformat(filename, Options{})
+	require.NoError(t, err)
+	d, err := Diff(filename, changes)
+	require.NoError(t, err)
+	assert.Contains(t, d, "\n-\t\"os\" "+comment+"\n")
+	assert.Contains(t, d, "\n+\t\"os\" "+comment+"\n")
+}
+
+func TestDiffNoTrailingNewline(t *testing.T) {
+	assert.Equal(t, `--- a/test.go
++++ b/test.go
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`, diff("test.go", "a\nb", "a\nb\n"))
+}
@@ -0,0 +1,157 @@
+package isort
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// An EditorConfig looks up settings from .editorconfig files (see https://editorconfig.org).
+// Only the properties we care about are read. Parsed files are cached so each is only read once.
+// It is safe for concurrent use.
+type EditorConfig struct {
+	mutex sync.Mutex
+	files map[string]*editorConfigFile
+}
+
+// An editorConfigFile is a single parsed .editorconfig file.
+type editorConfigFile struct {
+	root     bool
+	sections []editorConfigSection
+}
+
+// An editorConfigSection is a single section of a .editorconfig file.
+type editorConfigSection struct {
+	patterns   []string
+	properties map[string]string
+}
+
+// NewEditorConfig returns a new EditorConfig.
+func NewEditorConfig() *EditorConfig {
+	return &EditorConfig{files: map[string]*editorConfigFile{}}
+}
+
+// FinalNewline returns the value of insert_final_newline for the given file, and false if
+// it isn't set by any .editorconfig file.
+func (e *EditorConfig) FinalNewline(filename string) (insert bool, set bool) {
+	switch strings.ToLower(e.Property(filename, "insert_final_newline")) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
+
+// Property returns the value of the given property for a file, or the empty string if it isn't set.
+// Closer .editorconfig files take precedence over ones further up, and later sections within a
+// file over earlier ones, as the spec requires.
+func (e *EditorConfig) Property(filename, name string) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return ""
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		f := e.file(dir)
+		if value, present := f.property(dir, abs, name); present {
+			return value
+		} else if f.root || filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// file returns the parsed .editorconfig in the given directory. The mutex must be held.
+func (e *EditorConfig) file(dir string) *editorConfigFile {
+	if f, present := e.files[dir]; present {
+		return f
+	}
+	f := parseEditorConfig(filepath.Join(dir, ".editorconfig"))
+	e.files[dir] = f
+	return f
+}
+
+// property returns the value of a property for the given file in this .editorconfig, which is in dir.
+func (f *editorConfigFile) property(dir, filename, name string) (string, bool) {
+	rel, err := filepath.Rel(dir, filename)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	for i := len(f.sections) - 1; i >= 0; i-- {
+		section := f.sections[i]
+		if value, present := section.properties[name]; present && section.matches(rel) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// matches returns true if this section applies to the given file, relative to the .editorconfig.
+func (s *editorConfigSection) matches(rel string) bool {
+	for _, pattern := range s.patterns {
+		target := rel
+		if !strings.Contains(pattern, "/") {
+			target = filepath.Base(rel) // Patterns without a slash match files in any directory.
+		} else {
+			pattern = strings.TrimPrefix(pattern, "/")
+		}
+		// We don't fully support **, but treating it as * covers the common cases.
+		pattern = strings.Replace(pattern, "**", "*", -1)
+		if matched, _ := filepath.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEditorConfig parses the given .editorconfig file. If it doesn't exist, it's treated as empty.
+func parseEditorConfig(filename string) *editorConfigFile {
+	ec := &editorConfigFile{}
+	f, err := os.Open(filename)
+	if err != nil {
+		return ec
+	}
+	defer f.Close()
+	var section *editorConfigSection
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		} else if line[0] == '[' && line[len(line)-1] == ']' {
+			ec.sections = append(ec.sections, editorConfigSection{
+				patterns:   expandBraces(line[1 : len(line)-1]),
+				properties: map[string]string{},
+			})
+			section = &ec.sections[len(ec.sections)-1]
+		} else if idx := strings.IndexAny(line, "=:"); idx != -1 {
+			key := strings.ToLower(strings.TrimSpace(line[:idx]))
+			value := strings.TrimSpace(line[idx+1:])
+			if section != nil {
+				section.properties[key] = value
+			} else if key == "root" {
+				ec.root = strings.ToLower(value) == "true"
+			}
+		}
+	}
+	return ec
+}
+
+// expandBraces expands a single level of {a,b} alternatives in a glob pattern.
+func expandBraces(pattern string) []string {
+	start := strings.IndexRune(pattern, '{')
+	end := strings.IndexRune(pattern, '}')
+	if start == -1 || end < start {
+		return []string{pattern}
+	}
+	var ret []string
+	for _, alt := range strings.Split(pattern[start+1:end], ",") {
+		ret = append(ret, expandBraces(pattern[:start]+alt+pattern[end+1:])...)
+	}
+	return ret
+}
@@ -0,0 +1,49 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditorConfigFinalNewline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_editorconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, ".editorconfig"), "root = true\n\n[*]\ninsert_final_newline = true\n\n[*.{go,mod}]\ninsert_final_newline = false\n")
+	writeTestFile(t, filepath.Join(dir, "sub", ".editorconfig"), "[vendor/**.go]\ninsert_final_newline = true\n")
+
+	ec := NewEditorConfig()
+	assertFinalNewline(t, ec, filepath.Join(dir, "README.md"), true, true)
+	assertFinalNewline(t, ec, filepath.Join(dir, "sub", "pkg", "main.go"), false, true)
+	assertFinalNewline(t, ec, filepath.Join(dir, "sub", "vendor", "dep.go"), true, true)
+}
+
+func TestEditorConfigUnset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_editorconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, ".editorconfig"), "root = true\n\n[*.md]\ninsert_final_newline = true\n")
+	assertFinalNewline(t, NewEditorConfig(), filepath.Join(dir, "main.go"), false, false)
+}
+
+func TestNoFinalNewlineAdded(t *testing.T) {
+	src := []byte("package core\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)")
+	out, changed, err := Format(src, "test.go", Options{FinalNewline: false})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "package core\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)", string(out))
+	out, _, err = Format(src, "test.go", Options{FinalNewline: true})
+	require.NoError(t, err)
+	assert.Equal(t, "package core\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n", string(out))
+}
+
+func assertFinalNewline(t *testing.T, ec *EditorConfig, filename string, expectedInsert, expectedSet bool) {
+	insert, set := ec.FinalNewline(filename)
+	assert.Equal(t, expectedInsert, insert, filename)
+	assert.Equal(t, expectedSet, set, filename)
+}
@@ -0,0 +1,109 @@
+package isort
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Format reformats the imports of a Go source file held in memory and returns the new
+// contents, along with true if they differ from the original.
+// The filename is only used in error messages.
+func Format(src []byte, filename string, opts Options) ([]byte, bool, error) {
+	changes, err := reformat(filename, src, opts)
+	if err != nil {
+		return nil, false, err
+	} else if !changes.Needed {
+		return src, false, nil
+	}
+	out, err := Apply(src, changes)
+	return out, true, err
+}
+
+// maxStableRuns is how many times CheckStable formats a file before giving up on it settling down.
+const maxStableRuns = 3
+
+// CheckStable formats the given source repeatedly, and returns an error if it's still changing
+// after a few runs. Formatting should always settle after the first one; this is for debugging
+// cases where it doesn't, which otherwise show up as two runs flipping a file back and forth.
+func CheckStable(src []byte, filename string, opts Options) error {
+	for i := 0; i < maxStableRuns; i++ {
+		out, changed, err := Format(src, filename, opts)
+		if err != nil {
+			return err
+		} else if !changed {
+			return nil
+		}
+		src = out
+	}
+	return fmt.Errorf("%s: imports still changing after formatting %d times", filename, maxStableRuns)
+}
+
+// Apply applies a set of changes to the original contents of a file and returns the new contents.
+// Lines outside the import block are preserved exactly (except that a final newline is added if
+// changes.FinalNewline is set); the import block is written with whichever of LF or CRLF line
+// endings are dominant in the original. Any PostProcess hook is called on the block before
+// it's put in. If changes.Gofmt is set, the whole result is then
+// passed through go/format.
+func Apply(src []byte, changes *Changes) ([]byte, error) {
+	if !changes.Needed {
+		return src, nil
+	}
+	lines := strings.Split(string(src), "\n")
+	start, end, replacement := changes.Edit()
+	if len(lines) < end {
+		return nil, fmt.Errorf("Mismatching file lengths; expected at least %d but got %d", end, len(lines))
+	}
+	if changes.PostProcess != nil {
+		b, err := changes.PostProcess([]byte(replacement))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to post-process imports of %s: %s", changes.Filename, err)
+		}
+		replacement = string(b)
+	}
+	cr := ""
+	if isCRLF(src) {
+		cr = "\r"
+		replacement = strings.Replace(replacement, "\n", "\r\n", -1)
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(src) + len(replacement))
+	// The package clause always comes before the imports, so anything at the very start of the
+	// file (like a byte order mark) is in here and gets copied through untouched.
+	for _, line := range lines[:start-1] {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(replacement)
+	if end < len(lines) {
+		buf.WriteString(cr) // The newline itself is written below.
+	}
+	for _, line := range lines[end:] {
+		buf.WriteByte('\n')
+		buf.WriteString(line)
+	}
+	if changes.FinalNewline && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteString(cr + "\n")
+	}
+	if changes.Verify {
+		if _, err := parser.ParseFile(token.NewFileSet(), changes.Filename, buf.Bytes(), parser.ParseComments); err != nil {
+			return nil, fmt.Errorf("Reformatted %s fails to parse, not applying changes: %s", changes.Filename, err)
+		}
+	}
+	if changes.Gofmt {
+		out, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("Failed to gofmt %s: %s", changes.Filename, err)
+		}
+		return out, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// isCRLF returns true if the majority of lines in the given source end in CRLF.
+func isCRLF(src []byte) bool {
+	return 2*bytes.Count(src, []byte("\r\n")) > bytes.Count(src, []byte("\n"))
+}
@@ -0,0 +1,219 @@
+package isort
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	src, err := ioutil.ReadFile("isort/test_data/test2.go")
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("isort/test_data/test2_reformatted.go")
+	require.NoError(t, err)
+	out, changed, err := Format(src, "test2.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, string(expected), string(out))
+
+	out, changed, err = Format(expected, "test2.go", Options{})
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, string(expected), string(out))
+}
+
+func TestFormatMatchesRewrite(t *testing.T) {
+	// Format works entirely in memory, but should always give the same result as Rewrite.
+	files, err := filepath.Glob("isort/test_data/*.go")
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "goisort_format")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	opts := Options{LocalPackage: "github.com/peterebden/goisort"}
+	for _, filename := range files {
+		src, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		formatted, changed, err := Format(src, filename, opts)
+		require.NoError(t, err)
+		changes, err := Reformat(filename, opts)
+		require.NoError(t, err)
+		assert.Equal(t, changes.Needed, changed, filename)
+		out := filepath.Join(dir, filepath.Base(filename))
+		require.NoError(t, ioutil.WriteFile(out, src, 0644))
+		require.NoError(t, Rewrite(filename, out, changes))
+		rewritten, err := ioutil.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, string(rewritten), string(formatted), filename)
+	}
+}
+
+func TestFormatGofmt(t *testing.T) {
+	src, err := ioutil.ReadFile("isort/test_data/gofmt.go")
+	require.NoError(t, err)
+	out, changed, err := Format(src, "gofmt.go", Options{Gofmt: true})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	expected, err := format.Source(out)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(out))
+	assert.Contains(t, string(out), "var x = fmt.Sprintf")
+
+	// Doing it again shouldn't change anything.
+	out2, changed, err := Format(out, "gofmt.go", Options{Gofmt: true})
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, string(out), string(out2))
+}
+
+func TestFormatAlignsComments(t *testing.T) {
+	const src = `package core
+
+import (
+	"os" // for files
+	"fmt" // for printing
+	// The doc comment starts a new column.
+	"strings" // for strings
+	x "net/http" /* aliased */
+	"unicode/utf8"
+	"bytes" // after an uncommented import
+
+	"github.com/jessevdk/go-flags" // for flags
+)
+`
+	out, changed, err := Format([]byte(src), "aligned.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	// This should be exactly what gofmt would have done.
+	expected, err := format.Source(out)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(out))
+	assert.Contains(t, string(out), "\t\"os\"         // for files\n\t// The doc comment starts a new column.\n\t\"strings\" // for strings\n")
+
+	// It's now aligned, so it shouldn't need doing again.
+	_, changed, err = Format(out, "aligned.go", Options{})
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestFormatSingleImport(t *testing.T) {
+	for src, expected := range map[string]string{
+		// Aliased, with a doc comment and a trailing one.
+		"package x\n\nimport (\n\t// Doc for fmt.\n\tf \"fmt\" // trailing\n)\n\nvar _ = f.Sprint\n": "package x\n\n// Doc for fmt.\nimport f \"fmt\" // trailing\n\nvar _ = f.Sprint\n",
+		// A duplicate that's merged away, leaving one.
+		"package x\n\nimport (\n\t_ \"embed\"\n\t_ \"embed\"\n)\n": "package x\n\nimport _ \"embed\"\n",
+		// The doc comment on the declaration stays where it is.
+		"package x\n\n// Doc on decl.\nimport (\n\t\"fmt\"\n)\n": "package x\n\n// Doc on decl.\nimport \"fmt\"\n",
+	} {
+		out, changed, err := Format([]byte(src), "single.go", Options{Verify: true})
+		require.NoError(t, err)
+		assert.True(t, changed, src)
+		assert.Equal(t, expected, string(out))
+		_, changed, err = Format(out, "single.go", Options{})
+		require.NoError(t, err)
+		assert.False(t, changed, expected)
+	}
+	// These have to keep their parens so the comments after the import aren't lost.
+	for _, src := range []string{
+		"package x\n\nimport (\n\t\"fmt\"\n\t// \"os\"\n)\n",
+		"package x\n\nimport (\n\t\"fmt\"\n) // trailing\n",
+	} {
+		_, changed, err := Format([]byte(src), "single.go", Options{})
+		require.NoError(t, err)
+		assert.False(t, changed, src)
+	}
+}
+
+func TestFormatAlwaysParens(t *testing.T) {
+	const src = "package x\n\n// Doc for fmt.\nimport f \"fmt\" // trailing\n\nvar _ = f.Sprint\n"
+	out, changed, err := Format([]byte(src), "parens.go", Options{AlwaysParens: true, Verify: true})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "package x\n\n// Doc for fmt.\nimport (\n\tf \"fmt\" // trailing\n)\n\nvar _ = f.Sprint\n", string(out))
+	_, changed, err = Format(out, "parens.go", Options{AlwaysParens: true})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	// Without it, it goes straight back again.
+	out, changed, err = Format(out, "parens.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, src, string(out))
+}
+
+func TestCheckStable(t *testing.T) {
+	files, err := filepath.Glob("isort/test_data/*.go")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, filename := range files {
+		src, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.NoError(t, CheckStable(src, filename, Options{}), filename)
+		// It should settle after a single run, not just eventually.
+		out, _, err := Format(src, filename, Options{})
+		require.NoError(t, err)
+		_, changed, err := Format(out, filename, Options{})
+		assert.NoError(t, err)
+		assert.False(t, changed, "%s changes again when formatted a second time", filename)
+	}
+}
+
+func TestFormatPostProcess(t *testing.T) {
+	const src = "package core\n\nimport (\n\tflags \"github.com/jessevdk/go-flags\"\n\t\"fmt\"\n)\n\nvar x = flags.Default\n"
+	// Contrived, but easy to spot: uppercase all the aliases.
+	upperAliases := func(block []byte) ([]byte, error) {
+		lines := bytes.Split(block, []byte("\n"))
+		for i, line := range lines {
+			if idx := bytes.Index(line, []byte(` "`)); idx != -1 && bytes.HasPrefix(line, []byte("\t")) {
+				lines[i] = append(bytes.ToUpper(line[:idx]), line[idx:]...)
+			}
+		}
+		return bytes.Join(lines, []byte("\n")), nil
+	}
+	out, changed, err := Format([]byte(src), "post.go", Options{PostProcess: upperAliases})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	// Only the import block is transformed.
+	assert.Equal(t, "package core\n\nimport (\n\t\"fmt\"\n\n\tFLAGS \"github.com/jessevdk/go-flags\"\n)\n\nvar x = flags.Default\n", string(out))
+}
+
+func TestFormatPostProcessError(t *testing.T) {
+	fail := func(block []byte) ([]byte, error) { return nil, fmt.Errorf("nope") }
+	src, err := ioutil.ReadFile("isort/test_data/test2.go")
+	require.NoError(t, err)
+	_, _, err = Format(src, "test2.go", Options{PostProcess: fail})
+	assert.Error(t, err)
+}
+
+func TestFormatCRLF(t *testing.T) {
+	src, err := ioutil.ReadFile("isort/test_data/test2.go")
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("isort/test_data/test2_reformatted.go")
+	require.NoError(t, err)
+	out, changed, err := Format(crlf(src), "test2.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, string(crlf(expected)), string(out))
+}
+
+func TestFormatBOM(t *testing.T) {
+	const bom = "\xef\xbb\xbf"
+	src, err := ioutil.ReadFile("isort/test_data/test2.go")
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("isort/test_data/test2_reformatted.go")
+	require.NoError(t, err)
+	out, changed, err := Format(append([]byte(bom), src...), "test2.go", Options{Verify: true})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, bom+string(expected), string(out))
+}
+
+// crlf converts a file's line endings to CRLF.
+func crlf(src []byte) []byte {
+	return bytes.Replace(src, []byte("\n"), []byte("\r\n"), -1)
+}
@@ -0,0 +1,32 @@
+package isort
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// generatedRegex matches the comment marking generated files, as described at https://go.dev/s/generatedcode.
+var generatedRegex = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// IsGenerated returns true if the given file is marked as generated code, which shouldn't be
+// reformatted since it'll just get regenerated. Only the lines up to the package clause are read.
+// Any problem reading the file is left for whatever processes it next to report.
+func IsGenerated(filename string) bool {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if generatedRegex.MatchString(line) {
+			return true
+		} else if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsGenerated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_generated")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for contents, generated := range map[string]bool{
+		"// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage foo\n":                 true,
+		"// Copyright 2024\n\n// Code generated by stringer; DO NOT EDIT.\r\npackage foo\n": true,
+		"package foo\n\n// Code generated by protoc-gen-go. DO NOT EDIT.\n":                 false,
+		"// Code generated by protoc-gen-go. Please don't edit.\npackage foo\n":             false,
+		"package foo\n": false,
+	} {
+		filename := filepath.Join(dir, "a.go")
+		writeTestFile(t, filename, contents)
+		assert.Equal(t, generated, IsGenerated(filename), contents)
+	}
+	assert.False(t, IsGenerated(filepath.Join(dir, "doesnt_exist.go")))
+}
@@ -0,0 +1,38 @@
+package isort
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in test_data/golden with the current output")
+
+// TestGolden formats each *.in file in test_data/golden and compares the result to the matching
+// *.out file. Run with -update to regenerate them after adding a new case.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob("isort/test_data/golden/*.in")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, in := range files {
+		in := in
+		t.Run(strings.TrimSuffix(filepath.Base(in), ".in"), func(t *testing.T) {
+			src, err := ioutil.ReadFile(in)
+			require.NoError(t, err)
+			out, _, err := Format(src, in, Options{})
+			require.NoError(t, err)
+			golden := strings.TrimSuffix(in, ".in") + ".out"
+			if *update {
+				require.NoError(t, ioutil.WriteFile(golden, out, 0644))
+			}
+			expected, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(out))
+		})
+	}
+}
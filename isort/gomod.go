@@ -0,0 +1,77 @@
+package isort
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A ModuleFinder finds the module that files belong to, by looking for the nearest
+// enclosing go.mod file. Results are cached per directory so each go.mod is only read once.
+// It is safe for concurrent use.
+type ModuleFinder struct {
+	mutex sync.Mutex
+	dirs  map[string]string
+}
+
+// NewModuleFinder returns a new ModuleFinder.
+func NewModuleFinder() *ModuleFinder {
+	return &ModuleFinder{dirs: map[string]string{}}
+}
+
+// Module returns the module path for the given file, or the empty string if it isn't
+// within a module.
+func (m *ModuleFinder) Module(filename string) string {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return ""
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.module(dir)
+}
+
+// module returns the module path for a directory. The mutex must be held.
+func (m *ModuleFinder) module(dir string) string {
+	if mod, present := m.dirs[dir]; present {
+		return mod
+	}
+	mod, found := readModulePath(filepath.Join(dir, "go.mod"))
+	if !found {
+		if parent := filepath.Dir(dir); parent != dir {
+			mod = m.module(parent)
+		}
+	}
+	m.dirs[dir] = mod
+	return mod
+}
+
+// readModulePath reads the module path from a go.mod file.
+// It returns false if the file doesn't exist (or has no module directive).
+func readModulePath(filename string) (string, bool) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "//"); idx != -1 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+		mod := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if unquoted, err := strconv.Unquote(mod); err == nil {
+			mod = unquoted
+		}
+		return mod, true
+	}
+	return "", false
+}
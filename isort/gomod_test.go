@@ -0,0 +1,52 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nestedModuleFile = `package core
+
+import (
+	"fmt"
+
+	"example.com/root/lib"
+	"example.com/root/sub/lib"
+)
+`
+
+func TestNestedModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_gomod")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, "go.mod"), "module example.com/root\n\ngo 1.12\n")
+	writeTestFile(t, filepath.Join(dir, "sub", "go.mod"), "// The submodule\nmodule \"example.com/root/sub\" // trailing comment\n")
+	rootFile := filepath.Join(dir, "pkg", "a.go")
+	subFile := filepath.Join(dir, "sub", "pkg", "b.go")
+	writeTestFile(t, rootFile, nestedModuleFile)
+	writeTestFile(t, subFile, nestedModuleFile)
+
+	finder := NewModuleFinder()
+	assert.Equal(t, "example.com/root", finder.Module(rootFile))
+	assert.Equal(t, "example.com/root/sub", finder.Module(subFile))
+
+	// The same imports classify differently depending on which module the file is in.
+	// localPkg matches whole path elements so both imports are local to the root module.
+	changes, err := Reformat(rootFile, Options{LocalPackage: finder.Module(rootFile)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"fmt"`, "", `"example.com/root/lib"`, `"example.com/root/sub/lib"`}, importPaths(changes.Imports))
+	changes, err = Reformat(subFile, Options{LocalPackage: finder.Module(subFile)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"fmt"`, "", `"example.com/root/lib"`, "", `"example.com/root/sub/lib"`}, importPaths(changes.Imports))
+}
+
+// writeTestFile writes a file for a test, creating any directories needed.
+func writeTestFile(t *testing.T, filename, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, ioutil.WriteFile(filename, []byte(contents), 0644))
+}
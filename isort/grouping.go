@@ -0,0 +1,114 @@
+package isort
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// A GroupRule assigns imports matching a pattern to a named group.
+type GroupRule struct {
+	// The pattern to match import paths against. This is one of StdGroup, ThirdPartyGroup or
+	// LocalGroup to match imports of that kind, or a glob pattern (as path.Match) if it contains
+	// any of *?[, or otherwise an import path prefix as described on Options.Groups.
+	// Globs match if they match the whole path or any leading part of it, so "github.com/*"
+	// matches everything on github.com.
+	Pattern string
+	// The name of the group that matching imports go in. Groups are written in the order their
+	// names first appear in the rules.
+	Group string
+}
+
+// ParseGroupRule parses a rule of the form pattern=group. The pattern is everything before the
+// last =, so it can contain = itself but the group name can't.
+func ParseGroupRule(rule string) (GroupRule, error) {
+	idx := strings.LastIndexByte(rule, '=')
+	if idx == -1 {
+		return GroupRule{}, fmt.Errorf("invalid rule %s, must be of the form pattern=group", rule)
+	}
+	return GroupRule{Pattern: rule[:idx], Group: rule[idx+1:]}, nil
+}
+
+// grouping assigns imports to one of an ordered set of groups by a list of rules.
+// The first rule to match an import decides its group.
+type grouping struct {
+	rules        []groupingRule
+	defaultGroup int // Index of the group for imports that don't match any rule.
+}
+
+// A groupingRule is the compiled form of a GroupRule.
+type groupingRule struct {
+	pattern string
+	t       packageType // Type of import to match, or blankLine if it matches by pattern.
+	glob    bool
+	group   int
+}
+
+// newGrouping creates a new grouping from the given rules. Imports that don't match any go in the
+// named default group, or a group after all the others if that's empty or not otherwise named.
+func newGrouping(rules []GroupRule, defaultGroup string) grouping {
+	indices := map[string]int{}
+	index := func(name string) int {
+		if idx, present := indices[name]; present {
+			return idx
+		}
+		indices[name] = len(indices)
+		return indices[name]
+	}
+	g := grouping{rules: make([]groupingRule, len(rules))}
+	for i, rule := range rules {
+		g.rules[i] = newGroupingRule(rule.Pattern, index(rule.Group))
+	}
+	if _, present := indices[defaultGroup]; present {
+		g.defaultGroup = indices[defaultGroup]
+	} else {
+		g.defaultGroup = len(indices)
+	}
+	return g
+}
+
+// newGroupingRule creates a new rule matching the given pattern, as described on GroupRule.
+func newGroupingRule(pattern string, group int) groupingRule {
+	rule := groupingRule{
+		pattern: pattern,
+		t:       blankLine,
+		glob:    strings.ContainsAny(pattern, "*?["),
+		group:   group,
+	}
+	switch pattern {
+	case StdGroup:
+		rule.t = standardLibrary
+	case ThirdPartyGroup:
+		rule.t = thirdParty
+	case LocalGroup:
+		rule.t = localPackage
+	}
+	return rule
+}
+
+// Index returns the index of the group the given import path belongs in, given its type.
+func (g *grouping) Index(path string, t packageType) int {
+	for _, rule := range g.rules {
+		if rule.matches(path, t) {
+			return rule.group
+		}
+	}
+	return g.defaultGroup
+}
+
+// matches returns true if this rule matches the given import.
+func (r *groupingRule) matches(importPath string, t packageType) bool {
+	if r.t != blankLine {
+		return r.t == t
+	} else if !r.glob {
+		return hasPathPrefix(importPath, r.pattern)
+	}
+	for i := 0; i <= len(importPath); i++ {
+		if i == len(importPath) || importPath[i] == '/' {
+			if matched, _ := path.Match(r.pattern, importPath[:i]); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
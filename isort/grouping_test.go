@@ -0,0 +1,55 @@
+package isort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupingFirstMatchWins(t *testing.T) {
+	g := newGrouping([]GroupRule{
+		{Pattern: "github.com/peterebden/goisort/proto", Group: "proto"},
+		{Pattern: "github.com/peterebden", Group: "ours"},
+		{Pattern: StdGroup, Group: "std"},
+		{Pattern: "github.com/*", Group: "github"},
+		{Pattern: "github.com/peterebden/goisort", Group: "never"},
+	}, "")
+	assert.Equal(t, 0, g.Index("github.com/peterebden/goisort/proto", localPackage))
+	assert.Equal(t, 1, g.Index("github.com/peterebden/goisort", localPackage))
+	assert.Equal(t, 2, g.Index("fmt", standardLibrary))
+	assert.Equal(t, 3, g.Index("github.com/jessevdk/go-flags", thirdParty))
+	// Anything that doesn't match goes at the end.
+	assert.Equal(t, 5, g.Index("gopkg.in/yaml.v2", thirdParty))
+}
+
+func TestGroupingDefaultGroup(t *testing.T) {
+	g := newGrouping([]GroupRule{
+		{Pattern: StdGroup, Group: "std"},
+		{Pattern: "*.pb", Group: "protos"},
+		{Pattern: "github.com/peterebden", Group: "ours"},
+	}, "std")
+	assert.Equal(t, 0, g.Index("fmt", standardLibrary))
+	assert.Equal(t, 0, g.Index("github.com/jessevdk/go-flags", thirdParty))
+	assert.Equal(t, 1, g.Index("isort.pb", localPackage))
+	assert.Equal(t, 2, g.Index("github.com/peterebden/goisort", localPackage))
+}
+
+func TestGroupRules(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test2.go", Options{
+		GroupRules: []GroupRule{
+			{Pattern: "gopkg.in/", Group: "gopkg"},
+			{Pattern: StdGroup, Group: "std"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"gopkg.in/op/go-logging.v1"`,
+		"",
+		`"fmt"`,
+		`"os"`,
+		`"path"`,
+		`"strings"`,
+		"",
+		`"github.com/jessevdk/go-flags"`,
+	}, importPaths(changes.Imports))
+}
@@ -0,0 +1,104 @@
+package isort
+
+import "strings"
+
+// Names that can be used in Options.Groups to refer to the default groups.
+const (
+	StdGroup        = "std"
+	ThirdPartyGroup = "thirdparty"
+	LocalGroup      = "local"
+)
+
+// defaultGroups are the groups we use when none are configured.
+var defaultGroups = []string{StdGroup, ThirdPartyGroup, LocalGroup}
+
+// newGroups creates a grouping from the specs described on Options.Groups.
+func newGroups(specs []string) grouping {
+	if len(specs) == 0 {
+		specs = defaultGroups
+	}
+	// Prefixes take precedence over the default groups, wherever they are in the order, so
+	// their rules go first.
+	var prefixRules, defaultRules []groupingRule
+	group := 0
+	// addDefault adds rules for the given default groups (joined by +) as one group.
+	addDefault := func(spec string) {
+		added := false
+		for _, name := range strings.Split(spec, "+") {
+			if !hasRule(defaultRules, name) {
+				defaultRules = append(defaultRules, newGroupingRule(name, group))
+				added = true
+			}
+		}
+		if added {
+			group++
+		}
+	}
+	for _, spec := range specs {
+		if isDefaultGroups(spec) {
+			addDefault(spec)
+		} else {
+			for _, prefix := range strings.Split(spec, ",") {
+				prefixRules = append(prefixRules, newGroupingRule(prefix, group))
+			}
+			group++
+		}
+	}
+	// Any default groups that weren't mentioned go at the end, in their usual order.
+	for _, spec := range defaultGroups {
+		addDefault(spec)
+	}
+	return grouping{rules: append(prefixRules, defaultRules...), defaultGroup: group}
+}
+
+// withExtraGroups returns the given group specs with some extra ones inserted after whichever
+// has the standard library in it, or at the start if none of them do.
+func withExtraGroups(specs, extra []string) []string {
+	if len(extra) == 0 {
+		return specs
+	} else if len(specs) == 0 {
+		specs = defaultGroups
+	}
+	idx := 0
+	for i, spec := range specs {
+		if isDefaultGroups(spec) && strings.Contains("+"+spec+"+", "+"+StdGroup+"+") {
+			idx = i + 1
+			break
+		}
+	}
+	ret := make([]string, 0, len(specs)+len(extra))
+	ret = append(ret, specs[:idx]...)
+	ret = append(ret, extra...)
+	return append(ret, specs[idx:]...)
+}
+
+// isDefaultGroups returns true if the given group spec is one or more of the default groups,
+// joined by + (e.g. std+thirdparty).
+func isDefaultGroups(spec string) bool {
+	for _, name := range strings.Split(spec, "+") {
+		if name != StdGroup && name != ThirdPartyGroup && name != LocalGroup {
+			return false
+		}
+	}
+	return true
+}
+
+// hasRule returns true if any of the given rules have the given pattern.
+func hasRule(rules []groupingRule, pattern string) bool {
+	for _, rule := range rules {
+		if rule.pattern == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix returns true if the given import path has the given prefix, which must match
+// complete path elements unless it ends in a slash (so "github.com/foo" matches github.com/foo
+// and github.com/foo/bar but not github.com/foobar).
+func hasPathPrefix(path, prefix string) bool {
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
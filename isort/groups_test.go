@@ -0,0 +1,103 @@
+package isort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedProtoGroup(t *testing.T) {
+	changes, err := Reformat("isort/test_data/proto_group.go", Options{
+		LocalPackage: "github.com/peterebden/goisort",
+		Groups:       []string{StdGroup, ThirdPartyGroup, LocalGroup, "github.com/peterebden/goisort/proto"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"os"`,
+		"",
+		`"github.com/jessevdk/go-flags"`,
+		"",
+		`"github.com/peterebden/goisort/isort"`,
+		`"github.com/peterebden/goisort/protobuf"`,
+		"",
+		`"github.com/peterebden/goisort/proto/isort.pb"`,
+	}, importPaths(changes.Imports))
+}
+
+func TestUnlistedDefaultGroupsGoLast(t *testing.T) {
+	g := newGroups([]string{"golang.org/x/", StdGroup})
+	assert.Equal(t, 0, g.Index("golang.org/x/tools", thirdParty))
+	assert.Equal(t, 1, g.Index("fmt", standardLibrary))
+	assert.Equal(t, 2, g.Index("github.com/jessevdk/go-flags", thirdParty))
+	assert.Equal(t, 3, g.Index("github.com/peterebden/goisort", localPackage))
+}
+
+func TestCombinedDefaultGroups(t *testing.T) {
+	g := newGroups([]string{"std+thirdparty", LocalGroup})
+	assert.Equal(t, 0, g.Index("fmt", standardLibrary))
+	assert.Equal(t, 0, g.Index("github.com/jessevdk/go-flags", thirdParty))
+	assert.Equal(t, 1, g.Index("github.com/peterebden/goisort", localPackage))
+
+	// Within the merged group everything is sorted alphabetically with no blank lines.
+	changes, err := Reformat("isort/test_data/proto_group.go", Options{
+		LocalPackage: "github.com/peterebden/goisort",
+		Groups:       []string{"std+thirdparty", LocalGroup},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"github.com/jessevdk/go-flags"`,
+		`"os"`,
+		"",
+		`"github.com/peterebden/goisort/isort"`,
+		`"github.com/peterebden/goisort/proto/isort.pb"`,
+		`"github.com/peterebden/goisort/protobuf"`,
+	}, importPaths(changes.Imports))
+}
+
+func TestExtraGroups(t *testing.T) {
+	assert.Equal(t, []string{StdGroup, "golang.org/x/", ThirdPartyGroup, LocalGroup}, withExtraGroups(nil, []string{"golang.org/x/"}))
+	assert.Equal(t, []string{"std+local", "a,b", "c", ThirdPartyGroup}, withExtraGroups([]string{"std+local", ThirdPartyGroup}, []string{"a,b", "c"}))
+	assert.Equal(t, []string{"c", ThirdPartyGroup}, withExtraGroups([]string{ThirdPartyGroup}, []string{"c"}))
+	assert.Equal(t, []string{ThirdPartyGroup}, withExtraGroups([]string{ThirdPartyGroup}, nil))
+
+	const src = `package core
+
+import (
+	"fmt"
+	"github.com/jessevdk/go-flags"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/mod/semver"
+	"os"
+)
+`
+	out, changed, err := Format([]byte(src), "extra.go", Options{ExtraGroups: []string{"golang.org/x/"}})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, `package core
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/jessevdk/go-flags"
+)
+`, string(out))
+	// Without it, they're all third-party.
+	out, _, err = Format([]byte(src), "extra.go", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "\t\"github.com/jessevdk/go-flags\"\n\t\"golang.org/x/mod/semver\"\n")
+}
+
+func TestHasPathPrefix(t *testing.T) {
+	assert.True(t, hasPathPrefix("github.com/foo", "github.com/foo"))
+	assert.True(t, hasPathPrefix("github.com/foo/bar", "github.com/foo"))
+	assert.False(t, hasPathPrefix("github.com/foobar", "github.com/foo"))
+	assert.True(t, hasPathPrefix("golang.org/x/tools", "golang.org/x/"))
+	assert.False(t, hasPathPrefix("golang.org/x", "golang.org/x/"))
+}
@@ -0,0 +1,150 @@
+package isort
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFilename is the name of the file listing paths to skip when traversing directories.
+const IgnoreFilename = ".goisortignore"
+
+// An IgnoreFile is a parsed .goisortignore file. It uses the same syntax as .gitignore, and
+// its patterns are relative to the directory containing it.
+type IgnoreFile struct {
+	dir      string
+	patterns []ignorePattern
+}
+
+// An ignorePattern is a single line of an ignore file.
+type ignorePattern struct {
+	elements []string // Path elements of the pattern; ** matches any number of them.
+	negate   bool     // True if this re-includes paths (i.e. it started with a !)
+	dirOnly  bool     // True if this only matches directories (i.e. it ended with a /)
+}
+
+// FindIgnoreFile finds the .goisortignore file that applies to the given directory.
+// It's looked for in that directory and then each parent up to the repo root (the first
+// one containing .git). It returns nil if there isn't one.
+func FindIgnoreFile(dir string) (*IgnoreFile, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if f, err := ParseIgnoreFile(filepath.Join(dir, IgnoreFilename)); err == nil {
+			return f, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		} else if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || filepath.Dir(dir) == dir {
+			return nil, nil
+		}
+	}
+}
+
+// ParseIgnoreFile parses the given ignore file.
+func ParseIgnoreFile(filename string) (*IgnoreFile, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ignore := &IgnoreFile{dir: filepath.Dir(abs)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if pattern, ok := parseIgnorePattern(scanner.Text()); ok {
+			ignore.patterns = append(ignore.patterns, pattern)
+		}
+	}
+	return ignore, scanner.Err()
+}
+
+// parseIgnorePattern parses a single line of an ignore file. It returns false if it's blank or a comment.
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " \t\r")
+	}
+	if line == "" || line[0] == '#' {
+		return ignorePattern{}, false
+	}
+	pattern := ignorePattern{}
+	if line[0] == '!' {
+		pattern.negate = true
+		line = line[1:]
+	} else if line[0] == '\\' {
+		line = line[1:] // Escapes a leading # or !
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+	if !strings.Contains(line, "/") {
+		// Patterns without a slash match at any level.
+		pattern.elements = []string{"**", line}
+	} else {
+		pattern.elements = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	}
+	return pattern, true
+}
+
+// Match returns true if the given path should be ignored. Later patterns take precedence over
+// earlier ones, as in .gitignore. Paths outside the ignore file's directory are never ignored.
+// Anything inside an ignored directory is ignored too, but callers traversing a tree will
+// usually just skip those directories.
+func (f *IgnoreFile) Match(path string, isDir bool) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(f.dir, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	elements := strings.Split(filepath.ToSlash(rel), "/")
+	// Check the parent directories first; once one is ignored nothing within it can be re-included.
+	for i := 1; i < len(elements); i++ {
+		if f.match(elements[:i], true) {
+			return true
+		}
+	}
+	return f.match(elements, isDir)
+}
+
+// match returns true if the given path elements are ignored, not considering their parents.
+func (f *IgnoreFile) match(elements []string, isDir bool) bool {
+	for i := len(f.patterns) - 1; i >= 0; i-- {
+		if p := f.patterns[i]; (isDir || !p.dirOnly) && matchIgnoreGlob(p.elements, elements) {
+			return !p.negate
+		}
+	}
+	return false
+}
+
+// matchIgnoreGlob returns true if the given path elements match those of a pattern.
+func matchIgnoreGlob(pattern, elements []string) bool {
+	if len(pattern) == 0 {
+		return len(elements) == 0
+	} else if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(elements) > 0 // A trailing /** matches everything inside, but not the directory itself.
+		}
+		for i := range elements {
+			if matchIgnoreGlob(pattern[1:], elements[i:]) {
+				return true
+			}
+		}
+		return false
+	} else if len(elements) == 0 {
+		return false
+	}
+	matched, _ := filepath.Match(pattern[0], elements[0])
+	return matched && matchIgnoreGlob(pattern[1:], elements[1:])
+}
@@ -0,0 +1,60 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_ignore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, IgnoreFilename), `# Generated code
+*.pb.go
+!keep.pb.go
+/gen/
+docs/**/*.go
+build/
+\#hash.go
+`)
+	f, err := FindIgnoreFile(filepath.Join(dir, "a", "b"))
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	for path, ignored := range map[string]bool{
+		"main.go":              false,
+		"api.pb.go":            true,
+		"sub/api.pb.go":        true,
+		"sub/keep.pb.go":       false,
+		"gen/x.go":             true,
+		"sub/gen/x.go":         false,
+		"docs/x.go":            true,
+		"docs/a/b/x.go":        true,
+		"sub/docs/x.go":        false,
+		"build/x.go":           true,
+		"sub/build/x.go":       true,
+		"build.go":             false,
+		"#hash.go":             true,
+		"# Generated code":     false,
+		"../outside/api.pb.go": false,
+	} {
+		assert.Equal(t, ignored, f.Match(filepath.Join(dir, path), false), path)
+	}
+	assert.True(t, f.Match(filepath.Join(dir, "gen"), true))
+	assert.False(t, f.Match(filepath.Join(dir, "gen"), false))
+}
+
+func TestFindIgnoreFileStopsAtRepoRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_ignore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestFile(t, filepath.Join(dir, IgnoreFilename), "*.go\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "repo", ".git"), 0755))
+	f, err := FindIgnoreFile(filepath.Join(dir, "repo", "sub"))
+	require.NoError(t, err)
+	assert.Nil(t, f)
+}
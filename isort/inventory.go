@@ -0,0 +1,103 @@
+package isort
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An Inventory collects the imports used across a set of files, so that we can
+// check for inconsistencies between them.
+type Inventory struct {
+	uses map[string][]importUse
+}
+
+// An importUse is a single use of an import path in a file.
+type importUse struct {
+	Filename string
+	Line     int
+	Name     string
+}
+
+// An AliasWarning describes one use of an import path that is aliased differently
+// to other uses of it in other files.
+type AliasWarning struct {
+	Filename string // File the import is in.
+	Line     int    // Line the import is on.
+	Path     string // The import path, unquoted.
+	Name     string // The local name it's imported as, empty if it's not aliased.
+}
+
+func (w AliasWarning) String() string {
+	if w.Name == "" {
+		return fmt.Sprintf("%s:%d: %s is not aliased here but is aliased elsewhere", w.Filename, w.Line, w.Path)
+	}
+	return fmt.Sprintf("%s:%d: %s is aliased as %s here but differently elsewhere", w.Filename, w.Line, w.Path, w.Name)
+}
+
+// NewInventory returns a new, empty, Inventory.
+func NewInventory() *Inventory {
+	return &Inventory{uses: map[string][]importUse{}}
+}
+
+// Add adds the imports from a file to this inventory.
+func (inv *Inventory) Add(filename string, changes *Changes) {
+	for _, imp := range changes.Imports {
+		if imp.Path == "" || imp.Name == "_" || imp.Name == "." {
+			continue // Blank lines, side-effect and dot imports aren't aliases as such.
+		}
+		inv.uses[imp.Path] = append(inv.uses[imp.Path], importUse{
+			Filename: filename,
+			Line:     imp.Line,
+			Name:     imp.Name,
+		})
+	}
+}
+
+// DivergentAliases returns a warning for every use of an import path that isn't
+// aliased consistently across all the files in the inventory.
+// They are ordered by path, then by filename and line.
+func (inv *Inventory) DivergentAliases() []AliasWarning {
+	paths := make([]string, 0, len(inv.uses))
+	for path := range inv.uses {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	warnings := []AliasWarning{}
+	for _, path := range paths {
+		uses := inv.uses[path]
+		if consistentNames(uses) {
+			continue
+		}
+		sort.Slice(uses, func(i, j int) bool {
+			if uses[i].Filename != uses[j].Filename {
+				return uses[i].Filename < uses[j].Filename
+			}
+			return uses[i].Line < uses[j].Line
+		})
+		unquoted, err := strconv.Unquote(path)
+		if err != nil {
+			unquoted = strings.Trim(path, `"`)
+		}
+		for _, use := range uses {
+			warnings = append(warnings, AliasWarning{
+				Filename: use.Filename,
+				Line:     use.Line,
+				Path:     unquoted,
+				Name:     use.Name,
+			})
+		}
+	}
+	return warnings
+}
+
+// consistentNames returns true if all the given uses have the same name.
+func consistentNames(uses []importUse) bool {
+	for _, use := range uses[1:] {
+		if use.Name != uses[0].Name {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package isort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDivergentAliases(t *testing.T) {
+	inv := NewInventory()
+	for _, filename := range []string{"isort/test_data/alias1.go", "isort/test_data/alias2.go"} {
+		changes, err := Reformat(filename, Options{})
+		require.NoError(t, err)
+		inv.Add(filename, changes)
+	}
+	warnings := inv.DivergentAliases()
+	assert.Equal(t, []AliasWarning{
+		{Filename: "isort/test_data/alias1.go", Line: 6, Path: "github.com/golang/protobuf/proto", Name: "pb"},
+		{Filename: "isort/test_data/alias2.go", Line: 7, Path: "github.com/golang/protobuf/proto"},
+	}, warnings)
+	assert.Equal(t, "isort/test_data/alias1.go:6: github.com/golang/protobuf/proto is aliased as pb here but differently elsewhere", warnings[0].String())
+}
+
+func TestConsistentAliases(t *testing.T) {
+	inv := NewInventory()
+	changes, err := Reformat("isort/test_data/alias1.go", Options{})
+	require.NoError(t, err)
+	inv.Add("a.go", changes)
+	inv.Add("b.go", changes)
+	assert.Empty(t, inv.DivergentAliases())
+}
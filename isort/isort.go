@@ -5,22 +5,182 @@ package isort
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
 	"go/token"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"unicode/utf8"
+)
+
+// Options describes the configuration used when reformatting a file.
+type Options struct {
+	LocalPackage string   // Import path of the local package (e.g. github.com/peterebden/goisort), or several comma-separated.
+	ExtraStd     []string // Additional import paths to group (and sort) with the standard library.
+	// If set, packages under GOROOT/src are also treated as standard library, so any that are
+	// newer than the built-in list are still classified correctly.
+	GOROOT string
+	// The minor version of Go (e.g. 21 for Go 1.21) to classify standard library packages as of,
+	// so that packages added after it aren't treated as part of it. Defaults to the latest.
+	GoVersion     int
+	CommentGroups bool // Treat comments starting a section of the import block as fixed group headers.
+	// Treat every blank-line delimited section of the import block as a fixed group, and only
+	// sort within each one. Imports are never moved between sections. This takes precedence
+	// over CommentGroups and Groups.
+	RespectGroups bool
+	SortBy        SortKey  // What to sort imports by within each group. Defaults to SortByPath.
+	AliasedFirst  bool     // Sort aliased imports before unaliased ones of the same path.
+	TieBreak      TieBreak // How to order aliased imports of the same path. Defaults to TieBreakName.
+	// Compare the host part of import paths (before the first slash) case-insensitively, so
+	// Example.com/b sorts alongside example.com/a. The rest of the path is still case-sensitive.
+	HostCaseInsensitive bool
+	// Compare import paths case-insensitively, so github.com/Azure sorts next to github.com/azure.
+	// Paths that are the same ignoring case are still ordered case-sensitively.
+	CaseInsensitive bool
+	// Within each group, sort dot imports after all the others, and then blank (_) imports
+	// after those, rather than mixing them in by path. Imports with any other alias are still
+	// sorted by path along with unaliased ones on purpose, so AliasedFirst and TieBreak keep
+	// deciding between imports of the same path.
+	BlankImportsLast bool
+	// Separate standard library imports into families by the first element of their path
+	// (so all of net/... are together), with blank lines between each.
+	StdlibFamilies bool
+	// Files with at most this many imports are sorted alphabetically as a single group, with no
+	// blank lines. Zero disables this. It takes precedence over Groups, ExtraGroups, GroupRules
+	// and StdlibFamilies, which only apply to files with more imports than this, so there's
+	// nothing for GroupSpacing to separate either. Sections from CommentGroups and RespectGroups
+	// are still kept apart though, with GroupSpacing blank lines between them as usual.
+	MaxUngrouped int
+	WarnIndent   bool // Warn about imports in a parenthesised block that aren't indented with a single tab.
+	// Warn about imports that look like they're never used. This requires parsing the whole
+	// file rather than just its imports, and is only a heuristic. It has no effect on ReformatDecls.
+	WarnUnused bool
+	// The groups to sort imports into, in order. Each is either one of StdGroup, ThirdPartyGroup
+	// or LocalGroup (or several of them joined by +, like "std+thirdparty", to put them in one
+	// group with no blank lines between), or a comma-separated list of import path prefixes. Imports go into the first
+	// group with a matching prefix, otherwise into whichever of the default groups they belong in.
+	// Any of the default groups that aren't given are added at the end.
+	// Defaults to the standard library, then third-party, then local.
+	Groups []string
+	// Extra groups to put immediately after the standard library (wherever that is in Groups),
+	// each a comma-separated list of import path prefixes like those in Groups. For example,
+	// "golang.org/x/" puts golang.org/x packages in their own group before other third-party ones.
+	ExtraGroups []string
+	// Rules assigning imports to named groups, which replace Groups entirely if given.
+	// The first rule that matches an import decides its group; the groups are written in the
+	// order they're first named in. Imports that don't match any rule go in DefaultGroup,
+	// or in a group at the end if that's not set.
+	GroupRules   []GroupRule
+	DefaultGroup string
+	// Number of blank lines to separate groups by. Defaults to 1.
+	// Note that gofmt collapses more than one, so this isn't much use along with Gofmt.
+	GroupSpacing int
+	// Always write imports in a parenthesised block, even if there's only one of them.
+	AlwaysParens bool
+	// Only consider changes to be needed if imports are in the wrong order between groups,
+	// ignoring the order within each group. This is useful to adopt grouping incrementally.
+	CheckGroupsOnly bool
+	FinalNewline    bool // Ensure rewritten files end in a newline. Otherwise, whatever was there is preserved.
+	// Don't consider changes to be needed if the only difference is the whitespace between imports
+	// and their trailing comments (which is normalised whenever the block is rewritten).
+	IgnoreCommentWhitespace bool
+	// Check that the output of applying the changes still parses before returning it, and
+	// refuse to apply them if not. This is a safety net and isn't normally necessary.
+	Verify bool
+	// Run the reformatted file through go/format, so the import block is guaranteed to be
+	// formatted exactly as gofmt would. This formats the rest of the file too, and is slower
+	// since the whole file has to be parsed and printed.
+	Gofmt bool
+	// If set, this is called with the rewritten import block (from the import keyword to the
+	// closing paren, with LF line endings and no trailing newline) before it's put back into the
+	// file, and whatever it returns is used instead. It must return a valid import declaration.
+	// LineDelta and LineMap don't account for any lines it adds or removes.
+	PostProcess func(block []byte) ([]byte, error)
+	// If set, this is called for each import once it's been classified and sorted, with the index
+	// of the group it's in (by default 0 for the standard library, 1 for third-party and 2 for local).
+	Visit func(imp Import, group int)
+}
+
+// A SortKey determines what imports are sorted by within each group.
+type SortKey string
+
+const (
+	// SortByPath sorts imports by their full import path.
+	SortByPath SortKey = "path"
+	// SortByName sorts imports by the identifier they're imported as; that is, their alias
+	// if they have one, otherwise the last element of their path.
+	SortByName SortKey = "name"
+)
+
+// A TieBreak determines how imports of the same path are ordered, once aliased and unaliased
+// ones have been separated.
+type TieBreak string
+
+const (
+	// TieBreakName sorts imports of the same path by their alias.
+	TieBreakName TieBreak = "name"
+	// TieBreakOriginal keeps imports of the same path in the order they were originally in.
+	TieBreakOriginal TieBreak = "original"
 )
 
 // Changes describes the set of changes requested to a file.
 type Changes struct {
-	StartLine int      // Line that imports begin on, 1-indexed.
-	EndLine   int      // Line that imports end on
-	Imports   []Import // List of imports, in order.
-	Needed    bool     // True if changes are needed to this file.
+	Filename     string    // Name of the file these changes are for.
+	ImportLine   int       // Line the import keyword is on, 1-indexed.
+	ImportPrefix string    // Anything preceding the import keyword on the same line (e.g. the package clause).
+	StartLine    int       // Line that imports begin on, 1-indexed.
+	EndLine      int       // Line that imports end on
+	Rparen       int       // Line of the closing paren of the import block, 0 if it isn't parenthesised.
+	RparenSuffix string    // Anything following the closing paren on the same line (e.g. a comment).
+	Trailing     []string  // Comments between the last import and the closing paren, kept at the end of the block. Empty strings are blank lines.
+	Imports      []Import  // List of imports, in order.
+	Original     []Import  // List of imports as they were originally, including blank lines between them.
+	Needed       bool      // True if changes are needed to this file.
+	LineDelta    int       // Number of lines the import block grows by when the changes are applied (negative if it shrinks).
+	Warnings     []Warning // Any problems noticed with the imports that we can't fix ourselves.
+	Verify       bool      // True if Apply should check its output parses before returning it.
+	FinalNewline bool      // True if Apply should ensure its output ends in a newline.
+	GroupSpacing int       // Number of blank lines to write between groups; 0 counts as 1.
+	Gofmt        bool      // True if Apply should run its output through go/format.
+	// If set, Apply calls this on the rewritten import block before putting it into the file.
+	PostProcess func(block []byte) ([]byte, error)
+	parens      bool // True to write a parenthesised block even if there's only one import.
+}
+
+// A Warning describes a problem with a file's imports that doesn't stop us reformatting it.
+type Warning struct {
+	Line    int    // Line the problem is on, 1-indexed.
+	Message string // Description of the problem.
+}
+
+// A ParseError is returned when a file can't be parsed. It describes the first problem
+// the parser found.
+type ParseError struct {
+	Filename string
+	Line     int // Line the problem was on, 1-indexed, or 0 if it's not known.
+	Col      int // Column the problem was at, 1-indexed, or 0 if it's not known.
+	Msg      string
+}
+
+func (err *ParseError) Error() string {
+	if err.Line == 0 {
+		return err.Filename + ": " + err.Msg
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", err.Filename, err.Line, err.Col, err.Msg)
+}
+
+// newParseError converts an error from go/parser to a ParseError.
+func newParseError(filename string, err error) *ParseError {
+	if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+		return &ParseError{Filename: filename, Line: list[0].Pos.Line, Col: list[0].Pos.Column, Msg: list[0].Msg}
+	}
+	return &ParseError{Filename: filename, Msg: err.Error()}
 }
 
 // An Import describes a single import path.
@@ -29,6 +189,7 @@ type Import struct {
 	Path    string   // The import path
 	Doc     []string // Any preceding comment
 	Comment string   // Comment immediately after the import path.
+	Line    int      // Line the import was originally on, 1-indexed.
 }
 
 type packageType int
@@ -41,25 +202,141 @@ const (
 )
 
 // Reformat reformats an existing file and returns the details of changes to be made.
-func Reformat(filename, localPkg string) (*Changes, error) {
-	fset := token.FileSet{}
-	f, err := parser.ParseFile(&fset, filename, nil, parser.ImportsOnly)
+func Reformat(filename string, opts Options) (*Changes, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return reformat(filename, src, opts)
+}
+
+// reformat implements Reformat for the given file contents.
+func reformat(filename string, src []byte, opts Options) (*Changes, error) {
+	mode := parser.ImportsOnly | parser.ParseComments
+	if opts.WarnUnused {
+		// We need to see the rest of the file to know what's referred to.
+		mode = parser.ParseComments
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, mode)
+	if err != nil {
+		return nil, newParseError(filename, err)
+	}
+	changes, err := ReformatDecls(fset, filename, src, importDecls(f), opts)
 	if err != nil {
 		return nil, err
+	} else if opts.WarnUnused {
+		changes.Warnings = append(changes.Warnings, checkUnused(fset, f)...)
+	}
+	return changes, nil
+}
+
+// importDecls returns all the import declarations in a file.
+func importDecls(f *ast.File) []*ast.GenDecl {
+	var decls []*ast.GenDecl
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			decls = append(decls, gen)
+		}
+	}
+	return decls
+}
+
+// ReformatDecls is a lower-level version of Reformat for callers that have already parsed the file
+// (for example as part of a go/analysis pipeline), which avoids parsing it again.
+// It takes the file's contents and its import declarations, whose positions must be in fset.
+// Comments are taken from each ImportSpec's Doc and Comment fields, as go/parser populates them
+// when given parser.ParseComments; any others between imports are found in src and kept with
+// the import that follows them. The declarations are not modified.
+func ReformatDecls(fset *token.FileSet, filename string, src []byte, decls []*ast.GenDecl, opts Options) (*Changes, error) {
+	decls = withoutCgo(decls)
+	n := 0
+	for _, decl := range decls {
+		n += len(decl.Specs)
+	}
+	specs := make([]*ast.ImportSpec, 0, n)
+	// Doc comments on any declarations after the first would otherwise be lost when they're
+	// merged, so they're kept with the first import in each (indexed the same as specs).
+	var declDocs map[int]*ast.CommentGroup
+	for i, decl := range decls {
+		for j, spec := range decl.Specs {
+			if spec == nil {
+				continue
+			}
+			if spec, ok := spec.(*ast.ImportSpec); ok {
+				if spec == nil || spec.Path == nil {
+					// This shouldn't happen with anything from go/parser, but isn't impossible.
+					return nil, fmt.Errorf("%s: import spec has no path", fset.Position(decl.TokPos))
+				}
+				if i > 0 && j == 0 && decl.Doc != nil {
+					if declDocs == nil {
+						declDocs = map[int]*ast.CommentGroup{}
+					}
+					declDocs[len(specs)] = decl.Doc
+				}
+				specs = append(specs, spec)
+			}
+		}
+	}
+	// Generated files can have thousands of imports, so size this up front. It only needs to grow
+	// beyond this if there are blank lines.
+	changes := &Changes{
+		Filename:     filename,
+		Imports:      make([]Import, 0, len(specs)),
+		Verify:       opts.Verify,
+		FinalNewline: opts.FinalNewline,
+		GroupSpacing: opts.GroupSpacing,
+		Gofmt:        opts.Gofmt,
+		PostProcess:  opts.PostProcess,
+	}
+	if len(specs) == 0 {
+		// Nothing to sort; even if there are several empty blocks, we leave them be.
+		return changes, nil
+	}
+	if len(decls) > 0 {
+		// If there are several declarations, they're all replaced by one block.
+		pos := fset.Position(decls[0].TokPos)
+		changes.ImportLine = pos.Line
+		changes.ImportPrefix = linePrefix(src, pos.Offset, pos.Column)
+		if last := decls[len(decls)-1]; last.Rparen.IsValid() {
+			pos := fset.Position(last.Rparen)
+			changes.Rparen = pos.Line
+			changes.RparenSuffix = lineSuffix(src, pos.Offset+1)
+		}
 	}
-	changes := &Changes{}
-	for i, spec := range f.Imports {
+	respaced := false // True if any blank lines between groups would be respaced on rewriting.
+	for i, spec := range specs {
 		line := fset.Position(spec.Pos()).Line
 		if changes.StartLine == 0 {
 			changes.StartLine = line
 		}
-		if line > changes.EndLine+1 && i > 0 {
+		firstLine := line
+		doc := convertComment(spec.Doc)
+		if spec.Doc != nil {
+			firstLine = fset.Position(spec.Doc.Pos()).Line
+		}
+		if declDoc := declDocs[i]; declDoc != nil {
+			firstLine = fset.Position(declDoc.Pos()).Line
+			doc = append(convertComment(declDoc), doc...)
+		} else if i > 0 && sameDecl(decls, specs[i-1], spec) {
+			// Comments separated from the import by a blank line aren't attached to it by the parser,
+			// but they have to go somewhere; this keeps things like //nolint directives with it.
+			start := fset.Position(specEnd(specs[i-1])).Offset
+			floating := changes.attachComments(floatingComments(src, start, fset.Position(firstPos(spec)).Offset))
+			if len(floating) > 0 {
+				firstLine = changes.EndLine + floating[0].line
+				doc = append(commentTexts(floating), doc...)
+			}
+		}
+		if firstLine > changes.EndLine+1 && i > 0 {
 			changes.Imports = append(changes.Imports, Import{}) // blank line
+			respaced = respaced || firstLine-changes.EndLine-1 != changes.groupSpacing()
 		}
-		if spec.EndPos == 0 { // Not guaranteed to be set
-			spec.EndPos = spec.Path.Pos()
+		end := spec.EndPos
+		if end == 0 { // Not guaranteed to be set
+			end = spec.Path.Pos()
 		}
-		changes.EndLine = fset.Position(spec.EndPos).Line
+		changes.EndLine = fset.Position(end).Line
 		name := ""
 		if spec.Name != nil {
 			name = spec.Name.Name
@@ -67,57 +344,610 @@ func Reformat(filename, localPkg string) (*Changes, error) {
 		changes.Imports = append(changes.Imports, Import{
 			Path:    spec.Path.Value,
 			Name:    name,
-			Doc:     convertComment(spec.Doc),
+			Doc:     doc,
 			Comment: strings.Join(convertComment(spec.Comment), " "),
+			Line:    line,
 		})
 	}
-	// Keep a copy of the original so we can work out if it's changed later.
-	imps := changes.Imports
-	original := make([]Import, len(imps))
-	copy(original, imps)
-
-	stdPkgs := stdPkgMap()
-	cmp := func(a, b int) bool {
-		pathA := strings.Trim(imps[a].Path, `"`)
-		pathB := strings.Trim(imps[b].Path, `"`)
-		typeA := classifyPkg(pathA, localPkg, stdPkgs)
-		typeB := classifyPkg(pathB, localPkg, stdPkgs)
-		if typeA != typeB {
-			return typeA < typeB
-		} else if pathA != pathB {
-			return pathA < pathB
-		}
-		return imps[a].Name < imps[b].Name
-	}
-	sort.Slice(imps, cmp)
+	if last := decls[len(decls)-1]; last.Rparen.IsValid() {
+		// Anything after the last import (like a commented-out one) is kept at the end of the block.
+		start := fset.Position(specEnd(specs[len(specs)-1])).Offset
+		floating := changes.attachComments(floatingComments(src, start, fset.Position(last.Rparen).Offset))
+		changes.Trailing = trailingComments(floating)
+	}
+	changes.parens = opts.AlwaysParens
+	// Keep hold of the original so we can work out if it's changed later.
+	original := changes.Imports
+	changes.Original = original
+	s := newSorter(opts)
+	s.collapse = opts.MaxUngrouped > 0 && len(specs) <= opts.MaxUngrouped
+	if opts.RespectGroups {
+		changes.Imports = s.SortGroups(original)
+	} else if opts.CommentGroups {
+		changes.Imports = s.SortSections(original)
+	} else {
+		changes.Imports = s.Sort(original)
+	}
+	if opts.CheckGroupsOnly {
+		changes.Needed = s.groupsMisordered(original)
+	} else {
+		changes.Needed = importsDiffer(original, changes.Imports) || len(decls) > 1 || respaced
+		// A single import in parens gets written on one line (or the other way around with AlwaysParens).
+		changes.Needed = changes.Needed || decls[0].Lparen.IsValid() != changes.parenthesised()
+		// If the output is going through gofmt, it'll realign the comments however it likes.
+		if !changes.Needed && !opts.IgnoreCommentWhitespace && !opts.Gofmt {
+			changes.Needed = commentsRealigned(src, fset, specs, original, changes.parenthesised())
+		}
+	}
+	if changes.Needed {
+		changes.LineDelta = changes.lines() - (changes.endLine() - changes.ImportLine + 1)
+	}
+	if opts.Visit != nil {
+		for _, imp := range changes.Imports {
+			if imp.Path != "" {
+				opts.Visit(imp, s.group(imp))
+			}
+		}
+	}
+	changes.Warnings = s.Check(original)
+	if opts.WarnIndent && changes.Rparen != 0 {
+		changes.Warnings = append(changes.Warnings, checkIndent(src, original)...)
+	}
+	return changes, nil
+}
+
+// sameDecl returns true if both the given import specs are in the same one of the given declarations.
+func sameDecl(decls []*ast.GenDecl, a, b *ast.ImportSpec) bool {
+	for _, decl := range decls {
+		if decl.Pos() <= a.Pos() && a.Pos() < decl.End() {
+			return decl.Pos() <= b.Pos() && b.Pos() < decl.End()
+		}
+	}
+	return false
+}
+
+// specEnd returns the end of an import spec, including any trailing comment.
+func specEnd(spec *ast.ImportSpec) token.Pos {
+	if spec.Comment != nil {
+		return spec.Comment.End()
+	}
+	return spec.End()
+}
+
+// firstPos returns the start of an import spec, including its doc comment.
+func firstPos(spec *ast.ImportSpec) token.Pos {
+	if spec.Doc != nil {
+		return spec.Doc.Pos()
+	}
+	return spec.Pos()
+}
+
+// A floatingComment is a comment that the parser didn't attach to any import.
+type floatingComment struct {
+	text string
+	line int // Number of lines after the start of the region it was found in.
+}
+
+// floatingComments returns any comments in src between the given offsets, which should be
+// between two imports (or the last one and the closing paren).
+func floatingComments(src []byte, start, end int) []floatingComment {
+	if start < 0 || end > len(src) || start >= end || !bytes.Contains(src[start:end], []byte("/")) {
+		return nil // Nothing there but whitespace; this is the usual case, so avoid scanning it.
+	}
+	region := src[start:end]
+	file := token.NewFileSet().AddFile("", -1, len(region))
+	var s scanner.Scanner
+	s.Init(file, region, nil, scanner.ScanComments)
+	var comments []floatingComment
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return comments
+		} else if tok == token.COMMENT {
+			comments = append(comments, floatingComment{text: strings.TrimRight(lit, "\r"), line: file.Line(pos) - 1})
+		}
+	}
+}
+
+// attachComments adds any of the given comments that are on the same line as the last import
+// (like "os" /* a */; "fmt") to its trailing comment, and returns the rest.
+func (changes *Changes) attachComments(comments []floatingComment) []floatingComment {
+	for len(comments) > 0 && comments[0].line == 0 {
+		last := &changes.Imports[len(changes.Imports)-1]
+		if last.Comment == "" {
+			last.Comment = comments[0].text
+		} else {
+			last.Comment += " " + comments[0].text
+		}
+		comments = comments[1:]
+	}
+	return comments
+}
+
+// trailingComments returns the text of each of the given comments, with empty strings for any
+// blank lines before or between them.
+func trailingComments(comments []floatingComment) []string {
+	var ret []string
+	line := 0 // The line the previous comment (or the last import) ended on.
+	for _, c := range comments {
+		if c.line > line+1 {
+			ret = append(ret, "")
+		}
+		ret = append(ret, c.text)
+		line = c.line + strings.Count(c.text, "\n")
+	}
+	return ret
+}
+
+// commentTexts returns the text of each of the given comments.
+func commentTexts(comments []floatingComment) []string {
+	if len(comments) == 0 {
+		return nil
+	}
+	texts := make([]string, len(comments))
+	for i, c := range comments {
+		texts[i] = c.text
+	}
+	return texts
+}
+
+// withoutCgo returns the given import declarations without any that are just import "C".
+// cgo needs those to stay immediately after their preamble comment, so they're left exactly as
+// they are. Nothing can be merged across one either, so if there are others either side of one,
+// only the first run of them is returned.
+func withoutCgo(decls []*ast.GenDecl) []*ast.GenDecl {
+	start := 0
+	for start < len(decls) && isCgo(decls[start]) {
+		start++
+	}
+	end := start
+	for end < len(decls) && !isCgo(decls[end]) {
+		end++
+	}
+	return decls[start:end]
+}
+
+// isCgo returns true if the given declaration is a lone import "C".
+func isCgo(decl *ast.GenDecl) bool {
+	if len(decl.Specs) != 1 {
+		return false
+	}
+	spec, ok := decl.Specs[0].(*ast.ImportSpec)
+	return ok && spec != nil && spec.Path != nil && spec.Path.Value == `"C"`
+}
+
+// A sorter sorts imports into groups according to a set of options.
+type sorter struct {
+	localPkgs      []string
+	stdPkgs        map[string]struct{}
+	groups         grouping
+	byName         bool
+	aliasedFirst   bool
+	collapse       bool // True to sort everything as one group
+	keepOrder      bool // True to keep the original order of imports that otherwise sort equally
+	stdlibFamilies bool // True to separate standard library packages by family
+	hostFold       bool // True to compare hosts case-insensitively
+	caseFold       bool // True to compare whole paths case-insensitively
+	blankLast      bool // True to sort dot and blank imports at the end of each group
+}
+
+func newSorter(opts Options) *sorter {
+	groups := newGroups(withExtraGroups(opts.Groups, opts.ExtraGroups))
+	if len(opts.GroupRules) > 0 {
+		groups = newGrouping(opts.GroupRules, opts.DefaultGroup)
+	}
+	return &sorter{
+		localPkgs:      localPackages(opts.LocalPackage),
+		stdPkgs:        stdPkgMap(opts.ExtraStd, gorootStdlib(opts.GOROOT), opts.GoVersion),
+		groups:         groups,
+		byName:         opts.SortBy == SortByName,
+		aliasedFirst:   opts.AliasedFirst,
+		keepOrder:      opts.TieBreak == TieBreakOriginal,
+		stdlibFamilies: opts.StdlibFamilies,
+		hostFold:       opts.HostCaseInsensitive,
+		caseFold:       opts.CaseInsensitive,
+		blankLast:      opts.BlankImportsLast,
+	}
+}
+
+// Sort returns a sorted copy of the given imports, with blank lines between each group.
+// Any existing blank lines are discarded, as are duplicates of the same path and name.
+// Paths and names are compared byte-wise, which doesn't depend on the locale, so the output is
+// the same on every machine. Anything added here must keep that property; for example,
+// case-insensitive comparison must use fixed case folding rather than locale-aware collation.
+func (s *sorter) Sort(imps []Import) []Import {
+	sorted := make([]Import, 0, len(imps))
+	for _, imp := range imps {
+		if imp.Path != "" {
+			sorted = append(sorted, imp)
+		}
+	}
+	indices := make(map[string]int, len(sorted))
+	for _, imp := range sorted {
+		indices[imp.Path] = s.group(imp)
+	}
+	sort.SliceStable(sorted, func(a, b int) bool {
+		groupA := indices[sorted[a].Path]
+		groupB := indices[sorted[b].Path]
+		if groupA != groupB && !s.collapse {
+			return groupA < groupB
+		}
+		pathA := strings.Trim(sorted[a].Path, `"`)
+		pathB := strings.Trim(sorted[b].Path, `"`)
+		if familyA, familyB := s.family(pathA), s.family(pathB); familyA != familyB && !s.collapse {
+			return familyA < familyB
+		}
+		if s.blankLast {
+			if kindA, kindB := importKind(sorted[a].Name), importKind(sorted[b].Name); kindA != kindB {
+				return kindA < kindB
+			}
+		}
+		if s.byName {
+			if nameA, nameB := importName(sorted[a].Name, pathA), importName(sorted[b].Name, pathB); nameA != nameB {
+				return nameA < nameB
+			}
+		}
+		if pathA != pathB {
+			return s.pathLess(pathA, pathB)
+		}
+		nameA, nameB := sorted[a].Name, sorted[b].Name
+		if (nameA == "") != (nameB == "") {
+			return (nameB == "") == s.aliasedFirst
+		} else if s.keepOrder {
+			return false
+		}
+		return nameA < nameB
+	})
 	// Add spaces if required
-	imps2 := make([]Import, 0, len(imps)+2)
-	lastType := standardLibrary
-	for i, imp := range imps {
-		thisType := classifyPkg(strings.Trim(imp.Path, `"`), localPkg, stdPkgs)
-		if thisType != blankLine {
-			if thisType != lastType && i != 0 {
-				imps2 = append(imps2, Import{})
+	ret := make([]Import, 0, len(sorted)+2)
+	for i, imp := range sorted {
+		if i != 0 && imp.Path == sorted[i-1].Path && imp.Name == sorted[i-1].Name {
+			// An exact duplicate (e.g. after a bad merge) is dropped, but any comments on it are kept.
+			last := &ret[len(ret)-1]
+			last.Doc = append(last.Doc[:len(last.Doc):len(last.Doc)], imp.Doc...) // Don't write into the original's Doc
+			if last.Comment == "" {
+				last.Comment = imp.Comment
+			}
+			continue
+		}
+		if i != 0 && !s.collapse {
+			prev := sorted[i-1]
+			if indices[imp.Path] != indices[prev.Path] || s.family(strings.Trim(imp.Path, `"`)) != s.family(strings.Trim(prev.Path, `"`)) {
+				ret = append(ret, Import{})
 			}
-			imps2 = append(imps2, imp)
 		}
-		lastType = thisType
+		ret = append(ret, imp)
 	}
-	if len(imps2) != len(original) {
-		changes.Needed = true
-	} else {
-		for i, imp := range original {
-			if imp.Path != imps2[i].Path || imp.Name != imps2[i].Name {
-				changes.Needed = true
-				break
+	return ret
+}
+
+// pathLess returns true if import path a sorts before b.
+func (s *sorter) pathLess(a, b string) bool {
+	if s.caseFold {
+		if c := compareFold(a, b); c != 0 {
+			return c < 0
+		}
+	} else if s.hostFold {
+		hostA, restA := splitHost(a)
+		hostB, restB := splitHost(b)
+		if c := compareFold(hostA, hostB); c != 0 {
+			return c < 0
+		} else if restA != restB {
+			return restA < restB
+		}
+	}
+	return a < b
+}
+
+// splitHost splits an import path into its first element and the rest of it.
+func splitHost(path string) (string, string) {
+	if idx := strings.IndexByte(path, '/'); idx != -1 {
+		return path[:idx], path[idx:]
+	}
+	return path, ""
+}
+
+// importKind returns a rank for an import by its name, for Options.BlankImportsLast: normal and
+// aliased imports come first, then dot imports, then blank ones.
+func importKind(name string) int {
+	switch name {
+	case ".":
+		return 1
+	case "_":
+		return 2
+	}
+	return 0
+}
+
+// compareFold compares two strings with ASCII letters folded to lower case, returning -1, 0 or +1
+// like strings.Compare. Only ASCII is folded so the result doesn't depend on the locale.
+func compareFold(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if ca, cb := lowerASCII(a[i]), lowerASCII(b[i]); ca != cb {
+			if ca < cb {
+				return -1
 			}
+			return 1
 		}
 	}
-	changes.Imports = imps2
-	return changes, nil
+	if len(a) < len(b) {
+		return -1
+	} else if len(a) > len(b) {
+		return 1
+	}
+	return 0
+}
+
+// lowerASCII returns the lower case version of an ASCII letter, or the byte unchanged otherwise.
+func lowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// family returns the family of a standard library package (the first element of its path)
+// if we're separating them, or the empty string otherwise.
+func (s *sorter) family(path string) string {
+	if !s.stdlibFamilies || classifyPkg(path, s.localPkgs, s.stdPkgs) != standardLibrary {
+		return ""
+	}
+	if idx := strings.IndexByte(path, '/'); idx != -1 {
+		return path[:idx]
+	}
+	return path
+}
+
+// SortSections is like Sort, but treats each comment that starts a blank-line delimited
+// section as a header, and sorts within each section separately. The sections, and their
+// headers, retain their original order.
+func (s *sorter) SortSections(imps []Import) []Import {
+	return s.sortSections(imps, true, func(i int) bool {
+		return imps[i-1].Path == "" && len(imps[i].Doc) > 0
+	})
+}
+
+// SortGroups is like SortSections, but treats every blank-line delimited section as a fixed
+// group, which is sorted alphabetically without regard to what kind of imports are in it.
+// Nothing is moved between sections. Unlike SortSections, doc comments stay with their imports.
+func (s *sorter) SortGroups(imps []Import) []Import {
+	collapsed := *s
+	collapsed.collapse = true
+	return collapsed.sortSections(imps, false, func(i int) bool {
+		return imps[i-1].Path == ""
+	})
+}
+
+// sortSections implements SortSections and SortGroups. startsSection is called with the index
+// of each import after the first and returns true if a new section starts there. If headers is
+// true, the doc comment on the first import of each section is its header, and stays at the top.
+func (s *sorter) sortSections(imps []Import, headers bool, startsSection func(i int) bool) []Import {
+	var sections [][]Import
+	for i, imp := range imps {
+		if i == 0 || startsSection(i) {
+			sections = append(sections, nil)
+		}
+		sections[len(sections)-1] = append(sections[len(sections)-1], imp)
+	}
+	ret := make([]Import, 0, len(imps)+2*len(sections))
+	for i, section := range sections {
+		var header []string
+		if headers {
+			// Detach the header so it stays at the top of the section, whatever ends up there.
+			header = section[0].Doc
+			section[0].Doc = nil
+		}
+		sorted := s.Sort(section)
+		sorted[0].Doc = append(header, sorted[0].Doc...)
+		if i != 0 {
+			ret = append(ret, Import{})
+		}
+		ret = append(ret, sorted...)
+	}
+	return ret
+}
+
+// groupsMisordered returns true if any of the given imports are out of order between groups
+// (for example, a third-party import before a standard library one).
+func (s *sorter) groupsMisordered(imps []Import) bool {
+	if s.collapse {
+		return false // There's only one group.
+	}
+	last := 0
+	for _, imp := range imps {
+		if imp.Path == "" {
+			continue
+		} else if group := s.group(imp); group < last {
+			return true
+		} else {
+			last = group
+		}
+	}
+	return false
+}
+
+// Check returns warnings for any imports that look like mistakes.
+func (s *sorter) Check(imps []Import) []Warning {
+	var warnings []Warning
+	for _, imp := range imps {
+		path := strings.Trim(imp.Path, `"`)
+		if path == "" || path == "C" || strings.ContainsRune(path, '/') || isLocal(path, s.localPkgs) {
+			continue
+		} else if _, present := s.stdPkgs[path]; !present {
+			// Anything that isn't in the standard library should have at least a domain
+			// and a path; single elements like this are usually typos.
+			warnings = append(warnings, Warning{
+				Line:    imp.Line,
+				Message: fmt.Sprintf("import %s has only a single path element but is not in the standard library", imp.Path),
+			})
+		}
+	}
+	return append(warnings, checkAliases(imps)...)
+}
+
+// checkAliases returns warnings for any import paths that are imported more than once under
+// different names, which is almost always a mistake (typically from a bad merge).
+func checkAliases(imps []Import) []Warning {
+	// Sorting a copy by path is much cheaper than a map for files with huge numbers of imports.
+	sorted := make([]Import, 0, len(imps))
+	for _, imp := range imps {
+		if imp.Path != "" {
+			sorted = append(sorted, imp)
+		}
+	}
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Path < sorted[b].Path })
+	var warnings []Warning
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Path == sorted[i-1].Path && sorted[i].Name != sorted[i-1].Name {
+			warnings = append(warnings, Warning{
+				Line:    sorted[i].Line,
+				Message: fmt.Sprintf("import %s appears more than once with different names", sorted[i].Path),
+			})
+		}
+	}
+	return warnings
+}
+
+// checkIndent returns a warning for the first line of the given imports that isn't indented the
+// way we'd write it (with a single tab). Only the first is reported since they're typically all
+// the same way.
+func checkIndent(src []byte, imps []Import) []Warning {
+	lines := strings.Split(string(src), "\n")
+	for _, imp := range imps {
+		if imp.Path == "" || imp.Line > len(lines) {
+			continue
+		}
+		line := lines[imp.Line-1]
+		if trimmed := strings.TrimLeft(line, " \t"); line[:len(line)-len(trimmed)] != "\t" {
+			return []Warning{{
+				Line:    imp.Line,
+				Message: "import block is not indented with tabs",
+			}}
+		}
+	}
+	return nil
+}
+
+// group returns the index of the group a single import belongs in.
+func (s *sorter) group(imp Import) int {
+	path := strings.Trim(imp.Path, `"`)
+	return s.groups.Index(path, classifyPkg(path, s.localPkgs, s.stdPkgs))
+}
+
+// importName returns the identifier an import is referred to by; its alias if it has one,
+// otherwise the last element of its path (ignoring any major version suffix).
+func importName(alias, path string) string {
+	if alias != "" && alias != "_" && alias != "." {
+		return alias
+	}
+	elements := strings.Split(path, "/")
+	last := elements[len(elements)-1]
+	if len(elements) > 1 && isMajorVersion(last) {
+		return elements[len(elements)-2]
+	}
+	return last
+}
+
+// isMajorVersion returns true if the given path element is a major version suffix (e.g. v2).
+func isMajorVersion(element string) bool {
+	if len(element) < 2 || element[0] != 'v' {
+		return false
+	}
+	for _, r := range element[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// commentsRealigned returns true if any trailing comments would be respaced on rewriting.
+// The imports must be in the same order as the specs, with blank lines between them as needed.
+func commentsRealigned(src []byte, fset *token.FileSet, specs []*ast.ImportSpec, imps []Import, parenthesised bool) bool {
+	end, width := 0, 0
+	spec := 0
+	for i, imp := range imps {
+		if imp.Path == "" {
+			continue
+		} else if i >= end && parenthesised {
+			end, width = commentBlock(imps, i)
+		}
+		if s := specs[spec]; s.Comment != nil && !separatedBySpaces(src, fset.Position(s.End()).Offset, fset.Position(s.Comment.Pos()).Offset, commentPadding(imp, width)) {
+			return true
+		}
+		spec++
+	}
+	return false
+}
+
+// separatedBySpaces returns true if the given range of src is exactly n spaces.
+func separatedBySpaces(src []byte, start, end, n int) bool {
+	if start < 0 || end != start+n || end > len(src) {
+		return false
+	}
+	for _, b := range src[start:end] {
+		if b != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// commentBlock returns the end of the run of imports starting at i whose trailing comments are
+// aligned together, and the width of the widest of them. As with gofmt, the run is broken by
+// anything that isn't an import with a trailing comment, including a doc comment.
+// If imps[i] has no trailing comment, the run is just that import, with no width.
+func commentBlock(imps []Import, i int) (end, width int) {
+	if imps[i].Comment == "" {
+		return i + 1, 0
+	}
+	for end = i; end < len(imps); end++ {
+		if imp := imps[end]; imp.Path == "" || imp.Comment == "" || (end > i && len(imp.Doc) > 0) {
+			break
+		} else if w := importWidth(imp); w > width {
+			width = w
+		}
+	}
+	return end, width
+}
+
+// importWidth returns the width of an import as written, without its trailing comment.
+func importWidth(imp Import) int {
+	if imp.Name == "" {
+		return utf8.RuneCountInString(imp.Path)
+	}
+	return utf8.RuneCountInString(imp.Name) + 1 + utf8.RuneCountInString(imp.Path)
+}
+
+// commentPadding returns the number of spaces to write before an import's trailing comment to
+// align it with others of the given width. There's always at least one.
+func commentPadding(imp Import, width int) int {
+	if pad := width - importWidth(imp) + 1; pad > 1 {
+		return pad
+	}
+	return 1
+}
+
+// importsDiffer returns true if two lists of imports differ in a way that needs a rewrite.
+func importsDiffer(a, b []Import) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for i, imp := range a {
+		if imp.Path != b[i].Path || imp.Name != b[i].Name {
+			return true
+		}
+	}
+	return false
 }
 
 // Rewrite rewrites the contents of a file based on a set of changes.
+// Only the import block is altered; everything around it, including the presence or
+// absence of a blank line after the package clause, is left as it was.
+// infile and outfile can be the same to rewrite a file in place; the new contents are written
+// to a temporary file which is then renamed over it, so it's never left half-written.
+// The output keeps the mode of the file it replaces (or of infile, if it's new), and its owner
+// and group; if those can't be given to the temporary file, the file is overwritten in place instead.
 func Rewrite(infile, outfile string, changes *Changes) error {
 	if !changes.Needed {
 		return nil
@@ -126,39 +956,223 @@ func Rewrite(infile, outfile string, changes *Changes) error {
 	if err != nil {
 		return err
 	}
-	lines := strings.Split(string(b), "\n")
-	if len(lines) < changes.EndLine {
-		return fmt.Errorf("Mismatching file lengths; expected at least %d but got %d", changes.EndLine, len(lines))
+	out, err := Apply(b, changes)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(outfile)
+	owner := info // Only set if there's an existing file to replace.
+	if os.IsNotExist(err) {
+		info, err = os.Stat(infile)
+	} else if err == nil {
+		// Replace whatever a symlink points to, not the link itself.
+		if outfile, err = filepath.EvalSymlinks(outfile); err != nil {
+			return err
+		}
 	}
-	f, err := os.Create(outfile)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	w := bufio.NewWriter(f)
-	defer w.Flush()
-	for i := 0; i < changes.StartLine-1; i++ {
-		if strings.HasPrefix(lines[i], "import") {
-			break
+	return writeFile(outfile, out, info.Mode().Perm(), owner)
+}
+
+// writeFile writes a file via a temporary file in the same directory, which is renamed
+// over it once it's complete. If owner is non-nil, the file keeps the owner and group it
+// describes; if the temporary file can't be given them, the file is overwritten in place.
+func writeFile(filename string, contents []byte, mode os.FileMode, owner os.FileInfo) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	if err := writeTempFile(f, contents, mode); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if owner != nil {
+		if err := copyOwner(f.Name(), owner); err != nil {
+			// Writing over the original keeps its owner (and mode), at the cost of not being atomic.
+			os.Remove(f.Name())
+			return ioutil.WriteFile(filename, contents, mode)
 		}
-		w.WriteString(lines[i])
-		w.WriteRune('\n')
 	}
-	if len(changes.Imports) == 1 {
+	if err := os.Rename(f.Name(), filename); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// writeTempFile writes the contents of a temporary file, sets its mode and closes it.
+// It's synced to disk first so that a crash after the rename can't leave it empty.
+func writeTempFile(f *os.File, contents []byte, mode os.FileMode) error {
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Edit returns the minimal edit to apply these changes to the original file; that is,
+// the 1-indexed inclusive range of lines to replace and the text to replace them with.
+// The replacement does not have a trailing newline.
+// The range always covers the whole import declaration, including the line with its closing
+// paren, which is written afresh (along with anything that followed it on that line), and
+// the line with the import keyword, which keeps anything that preceded it.
+func (changes *Changes) Edit() (startLine, endLine int, replacement string) {
+	endLine = changes.endLine()
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	w.WriteString(changes.ImportPrefix)
+	if !changes.parenthesised() {
 		// Special case to write on a single line.
-		writeImport(w, changes.Imports[0], "")
+		imp := changes.Imports[0]
+		for _, doc := range imp.Doc {
+			w.WriteString(doc)
+			w.WriteRune('\n')
+		}
+		imp.Doc = nil
+		w.WriteString("import ")
+		writeImport(w, imp, "", 0)
 	} else {
 		w.WriteString("import (\n")
-		for _, imp := range changes.Imports {
-			writeImport(w, imp, "\t")
+		end, width := 0, 0
+		for i, imp := range changes.Imports {
+			if i >= end {
+				end, width = commentBlock(changes.Imports, i)
+			}
+			for j := changes.importLines(imp); j > 1; j-- {
+				w.WriteRune('\n') // Any more blank lines after the first, which writeImport writes.
+			}
+			writeImport(w, imp, "\t", width)
+		}
+		for _, comment := range changes.Trailing {
+			if comment != "" {
+				w.WriteRune('\t')
+				w.WriteString(comment)
+			}
+			w.WriteRune('\n')
 		}
+		w.WriteString(")")
+		w.WriteString(changes.RparenSuffix)
 		w.WriteRune('\n')
 	}
-	for i := changes.EndLine - 1; i < len(lines); i++ {
-		w.WriteString(lines[i])
-		w.WriteRune('\n')
+	w.Flush()
+	return changes.ImportLine, endLine, strings.TrimSuffix(buf.String(), "\n")
+}
+
+// endLine returns the last line of the original import declaration.
+func (changes *Changes) endLine() int {
+	if changes.Rparen != 0 {
+		return changes.Rparen
 	}
-	return nil
+	return changes.EndLine
+}
+
+// lines returns the number of lines in the replacement returned by Edit.
+// This avoids building it, since it's needed for every file.
+func (changes *Changes) lines() int {
+	n := 0
+	if changes.parenthesised() {
+		n += 2 // for the import ( and ) lines
+		for _, comment := range changes.Trailing {
+			n += strings.Count(comment, "\n") + 1
+		}
+	}
+	for _, imp := range changes.Imports {
+		for _, doc := range imp.Doc {
+			n += strings.Count(doc, "\n") + 1
+		}
+		n += changes.importLines(imp)
+	}
+	return n
+}
+
+// importLines returns the number of lines an import is written on, not counting its doc comment.
+// This is just one, unless it's a blank line between groups.
+func (changes *Changes) importLines(imp Import) int {
+	if imp.Path == "" {
+		return changes.groupSpacing()
+	}
+	return 1
+}
+
+// groupSpacing returns the number of blank lines to write between groups.
+func (changes *Changes) groupSpacing() int {
+	if changes.GroupSpacing > 1 {
+		return changes.GroupSpacing
+	}
+	return 1
+}
+
+// parenthesised returns true if the imports should be written in a parenthesised block.
+// A single import is written on one line, unless there's something after the paren (or any other
+// comments within them) to keep.
+func (changes *Changes) parenthesised() bool {
+	return len(changes.Imports) != 1 || changes.RparenSuffix != "" || len(changes.Trailing) > 0 || changes.parens
+}
+
+// LineMap returns a map of the original line of each import to the line it's on after the changes
+// are applied. Lines before the import block are unchanged; use MapLine to map any line.
+func (changes *Changes) LineMap() map[int]int {
+	m := make(map[int]int, len(changes.Imports))
+	line := changes.ImportLine
+	if changes.parenthesised() {
+		line++ // for the import ( line
+	}
+	for _, imp := range changes.Imports {
+		for _, doc := range imp.Doc {
+			line += strings.Count(doc, "\n") + 1
+		}
+		if imp.Line != 0 {
+			m[imp.Line] = line
+		}
+		line += changes.importLines(imp)
+	}
+	return m
+}
+
+// MapLine returns the line that the given original line is on after the changes are applied.
+// It returns 0 for lines within the import block that don't have an import on them (e.g. comments
+// or blank lines), since those don't necessarily survive in the same place.
+func (changes *Changes) MapLine(line int) int {
+	if !changes.Needed || line < changes.ImportLine {
+		return line
+	}
+	if line > changes.endLine() {
+		return line + changes.LineDelta
+	}
+	return changes.LineMap()[line]
+}
+
+// linePrefix returns whatever precedes the given offset on its line, which is at the given 1-indexed
+// column, or the empty string if that's only whitespace.
+func linePrefix(src []byte, offset, column int) string {
+	if offset < column-1 || offset > len(src) {
+		return ""
+	}
+	prefix := src[offset-column+1 : offset]
+	if len(bytes.TrimSpace(prefix)) == 0 {
+		return ""
+	}
+	return string(prefix)
+}
+
+// lineSuffix returns the rest of the line in src starting at the given offset, without its line ending.
+func lineSuffix(src []byte, offset int) string {
+	if offset < 0 || offset > len(src) {
+		return ""
+	}
+	rest := src[offset:]
+	if idx := bytes.IndexByte(rest, '\n'); idx != -1 {
+		rest = rest[:idx]
+	}
+	return string(bytes.TrimRight(rest, " \t\r"))
 }
 
 func convertComment(cg *ast.CommentGroup) []string {
@@ -172,21 +1186,39 @@ func convertComment(cg *ast.CommentGroup) []string {
 	return ret
 }
 
-func stdPkgMap() map[string]struct{} {
-	m := make(map[string]struct{}, len(stdlib))
+// stdPkgMap returns the set of standard library packages as of the given minor version of Go
+// (or the latest one if it's zero), plus those found under GOROOT and any extra ones given which
+// should be treated as though they were part of it.
+func stdPkgMap(extra, goroot []string, goVersion int) map[string]struct{} {
+	m := make(map[string]struct{}, len(stdlib)+len(stdlibAdded)+len(extra)+len(goroot))
 	for _, pkg := range stdlib {
 		m[pkg] = struct{}{}
 	}
+	for _, pkg := range goroot {
+		m[pkg] = struct{}{}
+	}
+	for pkg, version := range stdlibAdded {
+		if goVersion == 0 || version <= goVersion {
+			m[pkg] = struct{}{}
+		} else {
+			delete(m, pkg)
+		}
+	}
+	for _, pkg := range extra {
+		m[pkg] = struct{}{}
+	}
 	return m
 }
 
 // classifyPkg classifies a package into one of three buckets; standard library, third-party and local.
-func classifyPkg(name, localPkg string, stdPkgs map[string]struct{}) packageType {
+// Standard library membership comes only from the given set; after that the dot in the path is
+// just a last resort for telling third-party packages from local ones.
+func classifyPkg(name string, localPkgs []string, stdPkgs map[string]struct{}) packageType {
 	if name == "" {
 		return blankLine
 	} else if _, present := stdPkgs[name]; present {
 		return standardLibrary
-	} else if localPkg != "" && strings.HasPrefix(name, localPkg) {
+	} else if isLocal(name, localPkgs) {
 		return localPackage
 	} else if strings.ContainsRune(name, '.') {
 		// TODO(peter): this is a little dodgy as a derivation of what counts as
@@ -197,8 +1229,40 @@ func classifyPkg(name, localPkg string, stdPkgs map[string]struct{}) packageType
 	return localPackage
 }
 
+// localPackages returns the local package prefixes given in Options.LocalPackage.
+func localPackages(spec string) []string {
+	if spec == "" {
+		return nil
+	}
+	pkgs := strings.Split(spec, ",")
+	ret := pkgs[:0]
+	for _, pkg := range pkgs {
+		if pkg = strings.TrimSuffix(strings.TrimSpace(pkg), "/"); pkg != "" {
+			ret = append(ret, pkg)
+		}
+	}
+	return ret
+}
+
+// isLocal returns true if the given import path is within any of the given local packages.
+// They have to match whole path elements, so github.com/me/proj doesn't claim github.com/me/projector.
+// Where they overlap, it doesn't matter which matches since they're all local.
+func isLocal(path string, localPkgs []string) bool {
+	for _, pkg := range localPkgs {
+		if strings.HasPrefix(path, pkg) && (len(path) == len(pkg) || path[len(pkg)] == '/') {
+			return true
+		}
+	}
+	return false
+}
+
 // writeImport writes a single import to the given writer.
-func writeImport(w *bufio.Writer, imp Import, prefix string) {
+// Trailing comments are aligned as though the import was width characters wide.
+func writeImport(w *bufio.Writer, imp Import, prefix string, width int) {
+	if imp.Path == "" {
+		w.WriteRune('\n') // blank line between groups
+		return
+	}
 	for _, doc := range imp.Doc {
 		w.WriteString(prefix)
 		w.WriteString(doc)
@@ -210,5 +1274,11 @@ func writeImport(w *bufio.Writer, imp Import, prefix string) {
 		w.WriteRune(' ')
 	}
 	w.WriteString(imp.Path)
+	if imp.Comment != "" {
+		for i := commentPadding(imp, width); i > 0; i-- {
+			w.WriteRune(' ')
+		}
+		w.WriteString(imp.Comment)
+	}
 	w.WriteRune('\n')
 }
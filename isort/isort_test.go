@@ -1,37 +1,1120 @@
 package isort
 
 import (
+	"bytes"
+	"go/ast"
+	"go/token"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReformat1(t *testing.T) {
-	changes, err := Reformat("isort/test_data/test1.go", "")
+	changes, err := Reformat("isort/test_data/test1.go", Options{})
 	assert.NoError(t, err)
 	assert.False(t, changes.Needed)
 }
 
 func TestReformat2(t *testing.T) {
-	changes, err := Reformat("isort/test_data/test2.go", "")
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
 	assert.NoError(t, err)
 	assert.True(t, changes.Needed)
 }
 
+func TestOriginal(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"github.com/jessevdk/go-flags"`,
+		`"gopkg.in/op/go-logging.v1"`,
+		`"os"`,
+		`"path"`,
+		`"strings"`,
+	}, importPaths(changes.Original))
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"os"`,
+		`"path"`,
+		`"strings"`,
+		"",
+		`"github.com/jessevdk/go-flags"`,
+		`"gopkg.in/op/go-logging.v1"`,
+	}, importPaths(changes.Imports))
+}
+
 func TestClassifyPkg(t *testing.T) {
-	stdPkgs := stdPkgMap()
-	assert.Equal(t, standardLibrary, classifyPkg("strings", "", stdPkgs))
+	stdPkgs := stdPkgMap(nil, nil, 0)
+	assert.Equal(t, standardLibrary, classifyPkg("strings", nil, stdPkgs))
+	assert.Equal(t, standardLibrary, classifyPkg("net/http/httptest", nil, stdPkgs))
+	assert.EqualValues(t, localPackage, classifyPkg("mypkg", nil, stdPkgs))
+	assert.EqualValues(t, localPackage, classifyPkg("net/mypkg", nil, stdPkgs))
+	assert.EqualValues(t, thirdParty, classifyPkg("example.com/net/http", nil, stdPkgs))
+}
+
+func TestClassifyPkgGOROOT(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_goroot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, file := range []string{"fmt/print.go", "net/newpkg/newpkg.go", "net/README", "net/net.go", "net/newpkg/sub/sub.go", "net/newpkg/x.go", "net/onlytests/x_test.go", "internal/abi/abi.go", "vendor/golang.org/x/net/net.go", "cmd/go/main.go"} {
+		path := filepath.Join(dir, "src", file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	}
+	// Each package appears once, even though newpkg's files are walked either side of its subdirectory.
+	assert.Equal(t, []string{"fmt", "net", "net/newpkg", "net/newpkg/sub"}, gorootStdlib(dir))
+	s := newSorter(Options{GOROOT: dir})
+	assert.Equal(t, standardLibrary, classifyPkg("net/newpkg", s.localPkgs, s.stdPkgs))
+	assert.EqualValues(t, localPackage, classifyPkg("net/mypkg", s.localPkgs, s.stdPkgs))
+}
+
+func TestClassifyPkgLocalPackage(t *testing.T) {
+	stdPkgs := stdPkgMap(nil, nil, 0)
+	assert.EqualValues(t, localPackage, classifyPkg("github.com/me/proj", []string{"github.com/me/proj"}, stdPkgs))
+	assert.EqualValues(t, localPackage, classifyPkg("github.com/me/proj/sub", []string{"github.com/me/proj"}, stdPkgs))
+	assert.EqualValues(t, thirdParty, classifyPkg("github.com/me/projector", []string{"github.com/me/proj"}, stdPkgs))
+	assert.EqualValues(t, thirdParty, classifyPkg("github.com/me", []string{"github.com/me/proj"}, stdPkgs))
+}
+
+func TestLocalPackageTrailingSlash(t *testing.T) {
+	s := newSorter(Options{LocalPackage: "github.com/me/proj/"})
+	assert.EqualValues(t, localPackage, classifyPkg("github.com/me/proj", s.localPkgs, s.stdPkgs))
+	assert.EqualValues(t, localPackage, classifyPkg("github.com/me/proj/sub", s.localPkgs, s.stdPkgs))
+	assert.EqualValues(t, thirdParty, classifyPkg("github.com/me/projector", s.localPkgs, s.stdPkgs))
+}
+
+func TestMultipleLocalPackages(t *testing.T) {
+	s := newSorter(Options{LocalPackage: "github.com/ourco/app, github.com/ourco/shared/,github.com/ourco/app/internal"})
+	assert.Equal(t, []string{"github.com/ourco/app", "github.com/ourco/shared", "github.com/ourco/app/internal"}, s.localPkgs)
+	assert.EqualValues(t, localPackage, classifyPkg("github.com/ourco/app/cmd", s.localPkgs, s.stdPkgs))
+	assert.EqualValues(t, localPackage, classifyPkg("github.com/ourco/shared", s.localPkgs, s.stdPkgs))
+	assert.EqualValues(t, localPackage, classifyPkg("github.com/ourco/app/internal/x", s.localPkgs, s.stdPkgs))
+	assert.EqualValues(t, thirdParty, classifyPkg("github.com/ourco/other", s.localPkgs, s.stdPkgs))
+}
+
+func TestClassifyPkgGoVersion(t *testing.T) {
+	assert.Equal(t, standardLibrary, classifyPkg("slices", nil, stdPkgMap(nil, nil, 0)))
+	assert.Equal(t, standardLibrary, classifyPkg("slices", nil, stdPkgMap(nil, nil, 21)))
+	assert.NotEqual(t, standardLibrary, classifyPkg("slices", nil, stdPkgMap(nil, nil, 20)))
+	assert.Equal(t, standardLibrary, classifyPkg("strings", nil, stdPkgMap(nil, nil, 20)))
+}
+
+func TestNoImports(t *testing.T) {
+	for _, filename := range []string{"isort/test_data/no_imports.go", "isort/test_data/empty_imports.go"} {
+		changes, err := Reformat(filename, Options{})
+		require.NoError(t, err)
+		assert.False(t, changes.Needed, filename)
+		assert.Equal(t, 0, changes.StartLine, filename)
+		assert.Empty(t, changes.Imports, filename)
+		// Rewriting is a no-op, it doesn't even create the output.
+		out := filepath.Join(os.TempDir(), "goisort_no_imports.go")
+		assert.NoError(t, Rewrite(filename, out, changes))
+		_, err = os.Stat(out)
+		assert.True(t, os.IsNotExist(err), filename)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, _, err := Format([]byte("package core\n\nimport (\n\t\"fmt\n)\n"), "broken.go", Options{})
+	require.Error(t, err)
+	perr, ok := err.(*ParseError)
+	require.True(t, ok, "expected a *ParseError, got %T", err)
+	assert.Equal(t, "broken.go", perr.Filename)
+	assert.Equal(t, 4, perr.Line)
+	assert.Equal(t, 2, perr.Col)
+	assert.Equal(t, "string literal not terminated", perr.Msg)
+	assert.Equal(t, "broken.go:4:2: string literal not terminated", err.Error())
 }
 
 func TestRewrite2(t *testing.T) {
-	changes, err := Reformat("isort/test_data/test2.go", "")
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
 	assert.NoError(t, err)
 	err = Rewrite("isort/test_data/test2.go", "test2_reformatted.go", changes)
 	assert.NoError(t, err)
 	assertFilesEqual(t, "isort/test_data/test2_reformatted.go", "test2_reformatted.go")
 }
 
+func TestRewriteCopiesTrailingLinesOnce(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
+	assert.NoError(t, err)
+	err = Rewrite("isort/test_data/test2.go", "test2_trailing.go", changes)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadFile("test2_trailing.go")
+	assert.NoError(t, err)
+	// The last import line mustn't be copied again after the block, nor an extra newline added at the end.
+	assert.Equal(t, 1, strings.Count(string(b), `"strings"`))
+	assert.NotContains(t, string(b), "\t\n")
+	assert.True(t, strings.HasSuffix(string(b), ")\n\nvar log = logging.MustGetLogger(\"core\")\n"))
+}
+
+func TestEdit(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
+	assert.NoError(t, err)
+	start, end, replacement := changes.Edit()
+	assert.Equal(t, 3, start)
+	assert.Equal(t, 10, end)
+	b, err := ioutil.ReadFile("isort/test_data/test2.go")
+	assert.NoError(t, err)
+	lines := strings.Split(string(b), "\n")
+	edited := append(append(lines[:start-1:start-1], strings.Split(replacement, "\n")...), lines[end:]...)
+	err = Rewrite("isort/test_data/test2.go", "test2_edited.go", changes)
+	assert.NoError(t, err)
+	b, err = ioutil.ReadFile("test2_edited.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(b), strings.Join(edited, "\n"))
+}
+
+func TestCollapseBlankLines(t *testing.T) {
+	changes, err := Reformat("isort/test_data/blank_lines.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"os"`,
+		"",
+		`"github.com/jessevdk/go-flags"`,
+		`"gopkg.in/op/go-logging.v1"`,
+	}, importPaths(changes.Imports))
+	err = Rewrite("isort/test_data/blank_lines.go", "blank_lines_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/blank_lines_reformatted.go", "blank_lines_reformatted.go")
+}
+
+func TestGroupSpacing(t *testing.T) {
+	changes, err := Reformat("isort/test_data/blank_lines.go", Options{GroupSpacing: 2})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, -3, changes.LineDelta)
+	err = Rewrite("isort/test_data/blank_lines.go", "group_spacing_reformatted.go", changes)
+	require.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/group_spacing_reformatted.go", "group_spacing_reformatted.go")
+	// The same number of blank lines both times through.
+	changes, err = Reformat("group_spacing_reformatted.go", Options{GroupSpacing: 2})
+	require.NoError(t, err)
+	assert.False(t, changes.Needed)
+	// But with the default, they get collapsed.
+	changes, err = Reformat("group_spacing_reformatted.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+}
+
+func TestNolintDirectives(t *testing.T) {
+	changes, err := Reformat("isort/test_data/nolint.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+	// Every directive stays with the import it was on or above, even one separated from it
+	// by a blank line.
+	docs := map[string][]string{}
+	comments := map[string]string{}
+	for _, imp := range changes.Imports {
+		docs[imp.Path] = imp.Doc
+		comments[imp.Path] = imp.Comment
+	}
+	assert.Equal(t, []string{"//nolint:depguard"}, docs[`"github.com/b/c"`])
+	assert.Equal(t, []string{"//lint:ignore SA1019 deprecated"}, docs[`"bytes"`])
+	assert.Equal(t, []string{"//nolint:all"}, docs[`"io"`])
+	assert.Equal(t, "//nolint:gci", comments[`"fmt"`])
+	assert.Equal(t, "/* nolint */ //nolint:x", comments[`"errors"`])
+	assert.Nil(t, docs[`"os"`])
+	assert.Empty(t, comments[`"os"`])
+	err = Rewrite("isort/test_data/nolint.go", "nolint_reformatted.go", changes)
+	require.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/nolint_reformatted.go", "nolint_reformatted.go")
+}
+
+func TestClosingComments(t *testing.T) {
+	// Comments just before the closing paren, and an inline one between two imports, are all kept.
+	changes, err := Reformat("isort/test_data/closing_comments.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{`// "strings"`, "", "// TODO: add more"}, changes.Trailing)
+	err = Rewrite("isort/test_data/closing_comments.go", "closing_comments_reformatted.go", changes)
+	require.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/closing_comments_reformatted.go", "closing_comments_reformatted.go")
+
+	// As is a commented-out import after a blank line.
+	changes, err = Reformat("isort/test_data/commented_out.go", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", `// "strings"`}, changes.Trailing)
+	err = Rewrite("isort/test_data/commented_out.go", "commented_out_reformatted.go", changes)
+	require.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/commented_out_reformatted.go", "commented_out_reformatted.go")
+}
+
+func TestRewriteNoBlankLineAfterPackage(t *testing.T) {
+	// We leave the blank line after the package clause to gofmt; it shouldn't be added.
+	changes, err := Reformat("isort/test_data/tight.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/tight.go", "tight_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/tight_reformatted.go", "tight_reformatted.go")
+}
+
+func TestCommentGroups(t *testing.T) {
+	changes, err := Reformat("isort/test_data/comment_groups.go", Options{CommentGroups: true})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/comment_groups.go", "comment_groups_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/comment_groups_reformatted.go", "comment_groups_reformatted.go")
+}
+
+func TestRespectGroups(t *testing.T) {
+	changes, err := Reformat("isort/test_data/respect_groups.go", Options{RespectGroups: true, LocalPackage: "github.com/peterebden/goisort"})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/respect_groups.go", "respect_groups_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/respect_groups_reformatted.go", "respect_groups_reformatted.go")
+}
+
+func TestRespectGroupsDocComments(t *testing.T) {
+	// The first import in a group isn't a header for it; its comments move with it.
+	src := []byte(`package core
+
+import (
+	"os"
+
+	// zzz is needed for X
+	"github.com/zzz/z"
+	"github.com/aaa/a"
+
+	//nolint:depguard
+	"github.com/ccc/c"
+	"github.com/bbb/b"
+)
+`)
+	out, changed, err := Format(src, "respect_groups.go", Options{RespectGroups: true})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, `package core
+
+import (
+	"os"
+
+	"github.com/aaa/a"
+	// zzz is needed for X
+	"github.com/zzz/z"
+
+	"github.com/bbb/b"
+	//nolint:depguard
+	"github.com/ccc/c"
+)
+`, string(out))
+}
+
+func TestStdlibFamilies(t *testing.T) {
+	changes, err := Reformat("isort/test_data/stdlib_families.go", Options{StdlibFamilies: true})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/stdlib_families.go", "stdlib_families_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/stdlib_families_reformatted.go", "stdlib_families_reformatted.go")
+}
+
+func TestStdlibFamiliesOff(t *testing.T) {
+	changes, err := Reformat("isort/test_data/stdlib_families.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"encoding/base64"`, `"encoding/json"`, `"fmt"`, `"net"`, `"net/http"`, `"net/url"`, "", `"github.com/jessevdk/go-flags"`}, importPaths(changes.Imports))
+}
+
+func TestRparenComment(t *testing.T) {
+	out, changed, err := Format([]byte("package core\n\nimport (\n\t\"os\"\n\t\"fmt\"\n) // end imports\n\nvar x = fmt.Sprintf\n"), "test.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "package core\n\nimport (\n\t\"fmt\"\n\t\"os\"\n) // end imports\n\nvar x = fmt.Sprintf\n", string(out))
+}
+
+func TestRparen(t *testing.T) {
+	for name, rparen := range map[string]string{
+		"Plain":             ")",
+		"LeadingWhitespace": "  )",
+		"LeadingTab":        "\t)",
+		"TrailingComment":   ") // end imports",
+		"Both":              "\t) /* end imports */",
+	} {
+		t.Run(name, func(t *testing.T) {
+			src := "package core\n\nimport (\n\t\"os\"\n\t\"fmt\"\n" + rparen + "\n\nvar x = fmt.Sprintf\n"
+			out, changed, err := Format([]byte(src), "test.go", Options{})
+			require.NoError(t, err)
+			assert.True(t, changed)
+			expected := "package core\n\nimport (\n\t\"fmt\"\n\t\"os\"\n" + strings.TrimLeft(rparen, " \t") + "\n\nvar x = fmt.Sprintf\n"
+			assert.Equal(t, expected, string(out))
+		})
+	}
+}
+
+func TestRparenCommentSingleImport(t *testing.T) {
+	// The parens have to stay to keep the comment where it was.
+	changes, err := reformat("test.go", []byte("package core\n\nimport (\n\t\"fmt\"\n) // end imports\n"), Options{})
+	require.NoError(t, err)
+	start, end, replacement := changes.Edit()
+	assert.Equal(t, 3, start)
+	assert.Equal(t, 5, end)
+	assert.Equal(t, "import (\n\t\"fmt\"\n) // end imports", replacement)
+}
+
+func TestLineMap(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{4: 4, 5: 9, 6: 10, 7: 5, 8: 6, 9: 7}, changes.LineMap())
+	assert.Equal(t, 1, changes.MapLine(1))
+	assert.Equal(t, 9, changes.MapLine(5))
+	// The block has grown by one line for the blank between groups.
+	assert.Equal(t, 13, changes.MapLine(12))
+}
+
+func TestLineDelta(t *testing.T) {
+	// Grouping adds a blank line between the stdlib and third-party imports.
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, changes.LineDelta)
+	// Redundant blank lines are removed.
+	changes, err = Reformat("isort/test_data/blank_lines.go", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, -4, changes.LineDelta)
+	// Nothing changes if the file is already sorted.
+	changes, err = Reformat("isort/test_data/test1.go", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, changes.LineDelta)
+}
+
+func TestBlankLineBeforeGroupDoc(t *testing.T) {
+	// The blank line separating groups must come before a doc comment on the first import of a group,
+	// rather than between the comment and the import.
+	changes, err := Reformat("isort/test_data/group_doc.go", Options{LocalPackage: "github.com/peterebden/goisort"})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{`"fmt"`, "", `"github.com/jessevdk/go-flags"`, "", `"github.com/peterebden/goisort/isort"`}, importPaths(changes.Imports))
+	assert.Equal(t, []string{"// Our own code."}, changes.Imports[4].Doc)
+	err = Rewrite("isort/test_data/group_doc.go", "group_doc_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/group_doc_reformatted.go", "group_doc_reformatted.go")
+}
+
+func TestTrailingComments(t *testing.T) {
+	changes, err := Reformat("isort/test_data/trailing_comments.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/trailing_comments.go", "trailing_comments_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/trailing_comments_reformatted.go", "trailing_comments_reformatted.go")
+}
+
+func TestMixedDecls(t *testing.T) {
+	changes, err := Reformat("isort/test_data/mixed_decls.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/mixed_decls.go", "mixed_decls_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/mixed_decls_reformatted.go", "mixed_decls_reformatted.go")
+}
+
+func TestTwoBlocks(t *testing.T) {
+	changes, err := Reformat("isort/test_data/two_blocks.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, 3, changes.ImportLine)
+	assert.Equal(t, 11, changes.Rparen)
+	assert.Equal(t, -2, changes.LineDelta)
+	err = Rewrite("isort/test_data/two_blocks.go", "two_blocks_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/two_blocks_reformatted.go", "two_blocks_reformatted.go")
+}
+
+func TestMixedDeclsAlreadySorted(t *testing.T) {
+	// Even if they're in order, separate declarations still need merging.
+	out, changed, err := Format([]byte("package core\n\nimport \"fmt\"\nimport \"os\"\n"), "test.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "package core\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n", string(out))
+}
+
+func TestCgo(t *testing.T) {
+	changes, err := Reformat("isort/test_data/cgo.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, 7, changes.ImportLine)
+	err = Rewrite("isort/test_data/cgo.go", "cgo_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/cgo_reformatted.go", "cgo_reformatted.go")
+}
+
+func TestCgoBetweenDecls(t *testing.T) {
+	// The declarations either side of import "C" would swallow it if they were merged.
+	const src = "package core\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n\n// #include <stdlib.h>\nimport \"C\"\n\nimport \"bytes\"\n"
+	out, changed, err := Format([]byte(src), "cgo.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "package core\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\n// #include <stdlib.h>\nimport \"C\"\n\nimport \"bytes\"\n", string(out))
+}
+
+func TestLocaleIndependent(t *testing.T) {
+	// Sorting is byte-wise, so the environment's locale must make no difference.
+	var outputs []string
+	for _, locale := range []string{"C", "en_US.UTF-8", "tr_TR.UTF-8", "fr_FR.UTF-8"} {
+		for _, env := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+			old, present := os.LookupEnv(env)
+			os.Setenv(env, locale)
+			if present {
+				defer os.Setenv(env, old)
+			} else {
+				defer os.Unsetenv(env)
+			}
+		}
+		changes, err := Reformat("isort/test_data/locale.go", Options{})
+		require.NoError(t, err)
+		outputs = append(outputs, strings.Join(importPaths(changes.Imports), "\n"))
+	}
+	for _, output := range outputs[1:] {
+		assert.Equal(t, outputs[0], output)
+	}
+	assert.Equal(t, strings.Join([]string{
+		`"github.com/Istanbul/pkg"`,
+		`"github.com/Zeta/pkg"`,
+		`"github.com/eclair/pkg"`,
+		`"github.com/istanbul/pkg"`,
+		`"github.com/zeta/pkg"`,
+		`"github.com/éclair/pkg"`,
+		`"github.com/İstanbul/pkg"`,
+		`"github.com/ıstanbul/pkg"`,
+	}, "\n"), outputs[0])
+}
+
+func TestHostCaseInsensitive(t *testing.T) {
+	changes, err := Reformat("isort/test_data/host_case.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"Example.com/b"`, `"example.com/A"`, `"example.com/B"`, `"example.com/a"`, `"golang.org/x/tools"`}, importPaths(changes.Imports))
+
+	// The hosts are the same ignoring case, but each path is still compared case-sensitively after that.
+	changes, err = Reformat("isort/test_data/host_case.go", Options{HostCaseInsensitive: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"example.com/A"`, `"example.com/B"`, `"example.com/a"`, `"Example.com/b"`, `"golang.org/x/tools"`}, importPaths(changes.Imports))
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	changes, err := Reformat("isort/test_data/case.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"github.com/Azure/x"`, `"github.com/Beta/b"`, `"github.com/aws/z"`, `"github.com/azure/x"`, `"github.com/azure/y"`}, importPaths(changes.Imports))
+
+	// Ties in case-insensitive order are broken case-sensitively, so the order is still fixed.
+	changes, err = Reformat("isort/test_data/case.go", Options{CaseInsensitive: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"github.com/aws/z"`, `"github.com/Azure/x"`, `"github.com/azure/x"`, `"github.com/azure/y"`, `"github.com/Beta/b"`}, importPaths(changes.Imports))
+}
+
+func TestCompareFold(t *testing.T) {
+	assert.Equal(t, 0, compareFold("GitHub.com", "github.com"))
+	assert.Equal(t, 1, compareFold("github.com/Azure", "github.com/aws"))
+	assert.Equal(t, 1, compareFold("github.com/b", "github.com/A"))
+	assert.Equal(t, -1, compareFold("github.com", "github.com/a"))
+	// Non-ASCII isn't folded.
+	assert.Equal(t, 1, compareFold("İ", "i"))
+}
+
+func TestPreserveHeader(t *testing.T) {
+	// Everything before the imports (license, build constraints, package doc and clause) must
+	// come out byte-for-byte identical, even with no blank line before the imports.
+	src, err := ioutil.ReadFile("isort/test_data/preserve_header.go")
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("isort/test_data/preserve_header_reformatted.go")
+	require.NoError(t, err)
+	out, changed, err := Format(src, "preserve_header.go", Options{Verify: true})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, string(expected), string(out))
+	header := src[:bytes.Index(src, []byte("import ("))]
+	assert.True(t, bytes.HasPrefix(out, header))
+}
+
+func TestPreserveLicenseHeader(t *testing.T) {
+	for _, name := range []string{"license_header", "same_line_header"} {
+		filename := "isort/test_data/" + name + ".go"
+		src, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		changes, err := Reformat(filename, Options{Verify: true})
+		require.NoError(t, err)
+		assert.True(t, changes.Needed)
+		require.NoError(t, Rewrite(filename, name+"_reformatted.go", changes))
+		assertFilesEqual(t, "isort/test_data/"+name+"_reformatted.go", name+"_reformatted.go")
+		// Everything up to the import keyword is kept byte-for-byte.
+		b, err := ioutil.ReadFile(name + "_reformatted.go")
+		require.NoError(t, err)
+		header := src[:bytes.Index(src, []byte("import ("))]
+		assert.True(t, bytes.HasPrefix(b, header), name)
+	}
+}
+
+func TestRewriteBuildConstraint(t *testing.T) {
+	// The build constraint has to stay on the first line with its blank line after it, and the
+	// extra blank line before the imports mustn't be collapsed either.
+	changes, err := Reformat("isort/test_data/build_constraint.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/build_constraint.go", "build_constraint_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/build_constraint_reformatted.go", "build_constraint_reformatted.go")
+}
+
+func TestRewriteCRLF(t *testing.T) {
+	changes, err := Reformat("isort/test_data/crlf.go", Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/crlf.go", "crlf_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/crlf_reformatted.go", "crlf_reformatted.go")
+	// Everything outside the import block is byte-for-byte the same.
+	before, err := ioutil.ReadFile("isort/test_data/crlf.go")
+	require.NoError(t, err)
+	after, err := ioutil.ReadFile("crlf_reformatted.go")
+	require.NoError(t, err)
+	split := func(b []byte) ([]byte, []byte) {
+		return b[:bytes.Index(b, []byte("import ("))], b[bytes.Index(b, []byte(")\r\n")):]
+	}
+	beforeHead, beforeTail := split(before)
+	afterHead, afterTail := split(after)
+	assert.Equal(t, beforeHead, afterHead)
+	assert.Equal(t, beforeTail, afterTail)
+	assert.Equal(t, 0, bytes.Count(after, []byte("\n"))-bytes.Count(after, []byte("\r\n")), "all lines should end in CRLF")
+}
+
+func TestCheckGroupsOnly(t *testing.T) {
+	// test1.go is correctly grouped and sorted; swapping two stdlib imports only breaks the order within a group.
+	src, err := ioutil.ReadFile("isort/test_data/test1.go")
+	require.NoError(t, err)
+	unsorted := bytes.Replace(src, []byte("\t\"os\"\n\t\"path\"\n"), []byte("\t\"path\"\n\t\"os\"\n"), 1)
+	require.NotEqual(t, string(src), string(unsorted))
+	changes, err := reformat("test1.go", unsorted, Options{CheckGroupsOnly: true})
+	require.NoError(t, err)
+	assert.False(t, changes.Needed)
+	changes, err = reformat("test1.go", unsorted, Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+
+	// test2.go has third-party imports before stdlib ones.
+	changes, err = Reformat("isort/test_data/test2.go", Options{CheckGroupsOnly: true})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+}
+
+func TestBlankDocCommentLine(t *testing.T) {
+	changes, err := Reformat("isort/test_data/blank_doc.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{"// line one", "//", "// line two"}, changes.Imports[0].Doc)
+	err = Rewrite("isort/test_data/blank_doc.go", "blank_doc_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/blank_doc_reformatted.go", "blank_doc_reformatted.go")
+}
+
+func TestSingleElementImportWarning(t *testing.T) {
+	changes, err := Reformat("isort/test_data/single_element.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []Warning{
+		{Line: 6, Message: `import "example" has only a single path element but is not in the standard library`},
+	}, changes.Warnings)
+	// No warning if it is the local package.
+	changes, err = Reformat("isort/test_data/single_element.go", Options{LocalPackage: "example"})
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Warnings)
+}
+
+func TestSortByPathAndName(t *testing.T) {
+	changes, err := Reformat("isort/test_data/sort_by.go", Options{})
+	assert.NoError(t, err)
+	assert.False(t, changes.Needed)
+	assert.Equal(t, []string{
+		`"encoding/json"`,
+		`"fmt"`,
+		`"net/http"`,
+		`"os"`,
+		"",
+		`"github.com/jessevdk/go-flags"`,
+		`"github.com/peterebden/goisort/v2"`,
+	}, importPaths(changes.Imports))
+
+	changes, err = Reformat("isort/test_data/sort_by.go", Options{SortBy: SortByName})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"net/http"`,
+		`"encoding/json"`,
+		`"os"`,
+		"",
+		`"github.com/peterebden/goisort/v2"`, // goisort
+		`"github.com/jessevdk/go-flags"`,     // jflags
+	}, importPaths(changes.Imports))
+}
+
+func TestImportName(t *testing.T) {
+	assert.Equal(t, "fmt", importName("", "fmt"))
+	assert.Equal(t, "http", importName("", "net/http"))
+	assert.Equal(t, "pb", importName("pb", "github.com/golang/protobuf/proto"))
+	assert.Equal(t, "goisort", importName("", "github.com/peterebden/goisort/v2"))
+	assert.Equal(t, "pq", importName("_", "github.com/lib/pq"))
+}
+
+func TestDualAlias(t *testing.T) {
+	// Importing the same path under different names is two distinct imports; both must be kept,
+	// with the unaliased one first and then ordered by alias.
+	changes, err := Reformat("isort/test_data/dual_alias.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{`"fmt"`, "", `"github.com/foo/bar"`, `"github.com/foo/bar"`, `"github.com/foo/bar"`}, importPaths(changes.Imports))
+	assert.Equal(t, []string{"", "", "", "abar", "bar2"}, importNames(changes.Imports))
+}
+
+func TestDuplicates(t *testing.T) {
+	changes, err := Reformat("isort/test_data/duplicates.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{`"fmt"`, `"os"`, "", `"github.com/foo/bar"`, `"github.com/foo/bar"`}, importPaths(changes.Imports))
+	assert.Equal(t, []Warning{{Line: 9, Message: `import "github.com/foo/bar" appears more than once with different names`}}, changes.Warnings)
+	err = Rewrite("isort/test_data/duplicates.go", "duplicates_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/duplicates_reformatted.go", "duplicates_reformatted.go")
+}
+
+func TestBlankImportsLast(t *testing.T) {
+	changes, err := Reformat("isort/test_data/blank_imports.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"embed"`, `"fmt"`, `"os"`, "",
+		`"github.com/golang/protobuf/proto"`, `"github.com/lib/pq"`, `"github.com/onsi/ginkgo"`, `"github.com/onsi/gomega"`, `"github.com/onsi/gomega/types"`,
+	}, importPaths(changes.Imports))
+
+	changes, err = Reformat("isort/test_data/blank_imports.go", Options{BlankImportsLast: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"fmt"`, `"os"`, `"embed"`, "",
+		`"github.com/golang/protobuf/proto"`, `"github.com/onsi/gomega"`, `"github.com/onsi/gomega/types"`, `"github.com/onsi/ginkgo"`, `"github.com/lib/pq"`,
+	}, importPaths(changes.Imports))
+	// Other aliases are mixed in with unaliased imports by path, rather than coming before or after them.
+	assert.Equal(t, []string{"", "", "_", "", "pb", "", "types", ".", "_"}, importNames(changes.Imports))
+}
+
+func TestMaxUngrouped(t *testing.T) {
+	// test2.go has six imports; at or below the limit they're sorted as a single group.
+	changes, err := Reformat("isort/test_data/test2.go", Options{MaxUngrouped: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"github.com/jessevdk/go-flags"`,
+		`"gopkg.in/op/go-logging.v1"`,
+		`"os"`,
+		`"path"`,
+		`"strings"`,
+	}, importPaths(changes.Imports))
+	assert.False(t, changes.Needed)
+	// Above it they get grouped as normal.
+	changes, err = Reformat("isort/test_data/test2.go", Options{MaxUngrouped: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"os"`,
+		`"path"`,
+		`"strings"`,
+		"",
+		`"github.com/jessevdk/go-flags"`,
+		`"gopkg.in/op/go-logging.v1"`,
+	}, importPaths(changes.Imports))
+	assert.True(t, changes.Needed)
+}
+
+func TestMaxUngroupedPrecedence(t *testing.T) {
+	// Within the limit it wins over anything that would otherwise split the imports up.
+	changes, err := Reformat("isort/test_data/test2.go", Options{
+		MaxUngrouped:   6,
+		Groups:         []string{"gopkg.in/", StdGroup + "+" + ThirdPartyGroup},
+		StdlibFamilies: true,
+		GroupSpacing:   2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`"fmt"`,
+		`"github.com/jessevdk/go-flags"`,
+		`"gopkg.in/op/go-logging.v1"`,
+		`"os"`,
+		`"path"`,
+		`"strings"`,
+	}, importPaths(changes.Imports))
+	assert.False(t, changes.Needed)
+	// Sections from CommentGroups are still kept apart though, with GroupSpacing between them.
+	src, err := ioutil.ReadFile("isort/test_data/comment_groups.go")
+	require.NoError(t, err)
+	out, _, err := Format(src, "comment_groups.go", Options{MaxUngrouped: 5, CommentGroups: true, GroupSpacing: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, `package core
+
+import (
+	// Our own packages, which we like to see first.
+	"github.com/peterebden/goisort/cache"
+	"github.com/peterebden/goisort/isort"
+
+
+	// Everything else.
+	"fmt"
+	"github.com/jessevdk/go-flags"
+	"os"
+)
+
+var log = isort.Reformat
+`, string(out))
+}
+
+func TestWarnIndent(t *testing.T) {
+	changes, err := Reformat("isort/test_data/space_indent.go", Options{WarnIndent: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []Warning{{Line: 4, Message: "import block is not indented with tabs"}}, changes.Warnings)
+	changes, err = Reformat("isort/test_data/space_indent.go", Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Warnings)
+	changes, err = Reformat("isort/test_data/test1.go", Options{WarnIndent: true})
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Warnings)
+}
+
+func TestSpacingOnlyChanges(t *testing.T) {
+	// Correctly ordered, but missing a blank line between groups and with an extra one within a group.
+	changes, err := Reformat("isort/test_data/spacing.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	err = Rewrite("isort/test_data/spacing.go", "spacing_reformatted.go", changes)
+	assert.NoError(t, err)
+	assertFilesEqual(t, "isort/test_data/test1.go", "spacing_reformatted.go")
+	// The only changes are to blank lines.
+	before, err := ioutil.ReadFile("isort/test_data/spacing.go")
+	assert.NoError(t, err)
+	after, err := ioutil.ReadFile("spacing_reformatted.go")
+	assert.NoError(t, err)
+	assert.Equal(t, nonBlankLines(string(before)), nonBlankLines(string(after)))
+}
+
+func TestCommentWhitespace(t *testing.T) {
+	// Correctly ordered, but the trailing comment would be respaced if it were rewritten.
+	changes, err := Reformat("isort/test_data/comment_spacing.go", Options{})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	changes, err = Reformat("isort/test_data/comment_spacing.go", Options{IgnoreCommentWhitespace: true})
+	assert.NoError(t, err)
+	assert.False(t, changes.Needed)
+}
+
+func TestIdempotent(t *testing.T) {
+	// Reformatting a file that's just been rewritten should never find anything else to do.
+	files, err := filepath.Glob("isort/test_data/*.go")
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "goisort_idempotent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for name, opts := range map[string]Options{
+		"default":         {},
+		"comment groups":  {CommentGroups: true},
+		"respect groups":  {RespectGroups: true},
+		"max ungrouped":   {MaxUngrouped: 5},
+		"stdlib families": {StdlibFamilies: true},
+		"sort by name":    {SortBy: SortByName},
+	} {
+		for _, filename := range files {
+			changes, err := Reformat(filename, opts)
+			require.NoError(t, err)
+			out := filepath.Join(dir, filepath.Base(filename))
+			require.NoError(t, copyFile(filename, out))
+			require.NoError(t, Rewrite(out, out, changes))
+			changes, err = Reformat(out, opts)
+			require.NoError(t, err)
+			assert.False(t, changes.Needed, "%s needs changes after being rewritten with %s options", filename, name)
+		}
+	}
+}
+
+// copyFile copies a file for a test.
+func copyFile(from, to string) error {
+	b, err := ioutil.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(to, b, 0644)
+}
+
+// nonBlankLines returns all the lines of the given string that aren't blank.
+func nonBlankLines(s string) []string {
+	ret := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
+
+func TestVisit(t *testing.T) {
+	groups := map[string]int{}
+	_, err := Reformat("isort/test_data/test2.go", Options{
+		LocalPackage: "gopkg.in",
+		Visit: func(imp Import, group int) {
+			_, present := groups[imp.Path]
+			assert.False(t, present, "%s visited twice", imp.Path)
+			groups[imp.Path] = group
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		`"fmt"`:                          0,
+		`"os"`:                           0,
+		`"path"`:                         0,
+		`"strings"`:                      0,
+		`"github.com/jessevdk/go-flags"`: 1,
+		`"gopkg.in/op/go-logging.v1"`:    2,
+	}, groups)
+}
+
+func TestRewriteInPlace(t *testing.T) {
+	filename := copyToTemp(t, "isort/test_data/test2.go")
+	defer os.Remove(filename)
+	changes, err := Reformat(filename, Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+	require.NoError(t, Rewrite(filename, filename, changes))
+	assertFilesEqual(t, "isort/test_data/test2_reformatted.go", filename)
+	// Once it's been rewritten, there should be nothing more to do.
+	changes, err = Reformat(filename, Options{})
+	require.NoError(t, err)
+	assert.False(t, changes.Needed)
+}
+
+func TestRewriteInPlaceFailure(t *testing.T) {
+	filename := copyToTemp(t, "isort/test_data/test2.go")
+	defer os.Remove(filename)
+	changes, err := Reformat(filename, Options{})
+	require.NoError(t, err)
+	// Pretend the file has changed since it was parsed; the rewrite fails and must not truncate it.
+	changes.Rparen = 1000
+	assert.Error(t, Rewrite(filename, filename, changes))
+	assertFilesEqual(t, "isort/test_data/test2.go", filename)
+}
+
+func TestRewriteLeavesNoTempFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_rewrite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test2.go")
+	require.NoError(t, copyFile("isort/test_data/test2.go", filename))
+	changes, err := Reformat(filename, Options{})
+	require.NoError(t, err)
+	require.NoError(t, Rewrite(filename, filename, changes))
+	// And one that fails partway through.
+	changes.Rparen = 1000
+	changes.Needed = true
+	assert.Error(t, Rewrite(filename, filename, changes))
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "test2.go", files[0].Name())
+}
+
+func TestRewriteNotNeeded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort_rewrite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	changes, err := Reformat("isort/test_data/test2_reformatted.go", Options{})
+	require.NoError(t, err)
+	require.False(t, changes.Needed)
+	// Nothing needs doing, so it shouldn't even try to read or write anything.
+	out := filepath.Join(dir, "out.go")
+	require.NoError(t, Rewrite(filepath.Join(dir, "doesnt_exist.go"), out, changes))
+	_, err = os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRewritePreservesMode(t *testing.T) {
+	for _, mode := range []os.FileMode{0600, 0755} {
+		filename := copyToTemp(t, "isort/test_data/test2.go")
+		defer os.Remove(filename)
+		require.NoError(t, os.Chmod(filename, mode))
+		changes, err := Reformat(filename, Options{})
+		require.NoError(t, err)
+		require.NoError(t, Rewrite(filename, filename, changes))
+		assertFilesEqual(t, "isort/test_data/test2_reformatted.go", filename)
+		info, err := os.Stat(filename)
+		require.NoError(t, err)
+		assert.Equal(t, mode, info.Mode().Perm())
+	}
+}
+
+func TestRewriteThroughSymlink(t *testing.T) {
+	filename := copyToTemp(t, "isort/test_data/test2.go")
+	defer os.Remove(filename)
+	link := filename + ".link"
+	require.NoError(t, os.Symlink(filename, link))
+	defer os.Remove(link)
+	changes, err := Reformat(link, Options{})
+	require.NoError(t, err)
+	require.NoError(t, Rewrite(link, link, changes))
+	assertFilesEqual(t, "isort/test_data/test2_reformatted.go", filename)
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.True(t, info.Mode()&os.ModeSymlink != 0)
+}
+
+func TestVerifyPreventsBadWrite(t *testing.T) {
+	filename := copyToTemp(t, "isort/test_data/test2.go")
+	defer os.Remove(filename)
+	original, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+
+	changes, err := Reformat(filename, Options{Verify: true})
+	assert.NoError(t, err)
+	// Simulate a bug in working out where the import block ends; the old imports would be left
+	// after the new block and the result wouldn't compile.
+	changes.Rparen = changes.ImportLine
+	err = Rewrite(filename, filename, changes)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "fails to parse")
+	b, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, string(original), string(b))
+
+	// Without interference it should be fine.
+	changes, err = Reformat(filename, Options{Verify: true})
+	assert.NoError(t, err)
+	assert.NoError(t, Rewrite(filename, filename, changes))
+	assertFilesEqual(t, "isort/test_data/test2_reformatted.go", filename)
+}
+
+func TestAliasedFirst(t *testing.T) {
+	// By default the unaliased import of github.com/foo/bar comes before the aliased ones...
+	changes, err := Reformat("isort/test_data/dual_alias.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"fmt"`, "", `"github.com/foo/bar"`, `"github.com/foo/bar"`, `"github.com/foo/bar"`}, importPaths(changes.Imports))
+	assert.Equal(t, []string{"", "", "", "abar", "bar2"}, importNames(changes.Imports))
+	// ...and with AliasedFirst it comes after them.
+	changes, err = Reformat("isort/test_data/dual_alias.go", Options{AliasedFirst: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"fmt"`, "", `"github.com/foo/bar"`, `"github.com/foo/bar"`, `"github.com/foo/bar"`}, importPaths(changes.Imports))
+	assert.Equal(t, []string{"", "", "abar", "bar2", ""}, importNames(changes.Imports))
+}
+
+func TestTieBreak(t *testing.T) {
+	changes, err := Reformat("isort/test_data/dual_alias.go", Options{TieBreak: TieBreakName})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "", "", "abar", "bar2"}, importNames(changes.Imports))
+
+	changes, err = Reformat("isort/test_data/dual_alias.go", Options{TieBreak: TieBreakOriginal})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "", "", "bar2", "abar"}, importNames(changes.Imports))
+
+	changes, err = Reformat("isort/test_data/dual_alias.go", Options{TieBreak: TieBreakOriginal, AliasedFirst: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "", "bar2", "abar", ""}, importNames(changes.Imports))
+}
+
+func TestReformatDecls(t *testing.T) {
+	src := []byte("package core\n\nimport (\n\t\"os\"\n\t// Formatting\n\t\"fmt\"\n)\n")
+	fset := token.NewFileSet()
+	file := fset.AddFile("test.go", -1, len(src))
+	file.SetLinesForContent(src)
+	pos := func(line, col int) token.Pos {
+		return file.LineStart(line) + token.Pos(col-1)
+	}
+	decl := &ast.GenDecl{
+		TokPos: pos(3, 1),
+		Tok:    token.IMPORT,
+		Lparen: pos(3, 8),
+		Rparen: pos(7, 1),
+		Specs: []ast.Spec{
+			&ast.ImportSpec{Path: &ast.BasicLit{ValuePos: pos(4, 2), Kind: token.STRING, Value: `"os"`}},
+			&ast.ImportSpec{
+				Doc:  &ast.CommentGroup{List: []*ast.Comment{{Slash: pos(5, 2), Text: "// Formatting"}}},
+				Path: &ast.BasicLit{ValuePos: pos(6, 2), Kind: token.STRING, Value: `"fmt"`},
+			},
+		},
+	}
+	changes, err := ReformatDecls(fset, "test.go", src, []*ast.GenDecl{decl}, Options{})
+	require.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, 3, changes.ImportLine)
+	assert.Equal(t, 4, changes.StartLine)
+	assert.Equal(t, 6, changes.EndLine)
+	assert.Equal(t, 7, changes.Rparen)
+	assert.Equal(t, []string{`"fmt"`, `"os"`}, importPaths(changes.Imports))
+	out, err := Apply(src, changes)
+	require.NoError(t, err)
+	assert.Equal(t, "package core\n\nimport (\n\t// Formatting\n\t\"fmt\"\n\t\"os\"\n)\n", string(out))
+}
+
+func TestReformatDeclsNilPath(t *testing.T) {
+	src := []byte("package core\n\nimport \"os\"\n")
+	fset := token.NewFileSet()
+	file := fset.AddFile("test.go", -1, len(src))
+	file.SetLinesForContent(src)
+	decl := &ast.GenDecl{
+		TokPos: file.LineStart(3),
+		Tok:    token.IMPORT,
+		Specs:  []ast.Spec{&ast.ImportSpec{}},
+	}
+	_, err := ReformatDecls(fset, "test.go", src, []*ast.GenDecl{decl}, Options{})
+	assert.EqualError(t, err, "test.go:3:1: import spec has no path")
+
+	decl.Specs = []ast.Spec{nil, (*ast.ImportSpec)(nil)}
+	_, err = ReformatDecls(fset, "test.go", src, []*ast.GenDecl{decl}, Options{})
+	assert.Error(t, err)
+}
+
+func TestExtraStdSortsWithinStdlib(t *testing.T) {
+	changes, err := Reformat("isort/test_data/extra_std.go", Options{
+		ExtraStd: []string{"github.com/peterebden/ctxpoly"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, changes.Needed)
+	assert.Equal(t, []string{
+		`"errors"`,
+		`"fmt"`,
+		`"github.com/peterebden/ctxpoly"`,
+		`"io"`,
+		`"os"`,
+		"",
+		`"github.com/jessevdk/go-flags"`,
+	}, importPaths(changes.Imports))
+}
+
+// importPaths returns the paths of a set of imports, with blank lines as empty strings.
+func importPaths(imps []Import) []string {
+	ret := make([]string, len(imps))
+	for i, imp := range imps {
+		ret[i] = imp.Path
+	}
+	return ret
+}
+
+// importNames returns the names of a set of imports.
+func importNames(imps []Import) []string {
+	ret := make([]string, len(imps))
+	for i, imp := range imps {
+		ret[i] = imp.Name
+	}
+	return ret
+}
+
+// copyToTemp copies the given file to a new temporary file and returns its name.
+func copyToTemp(t *testing.T, filename string) string {
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	f, err := ioutil.TempFile("", "goisort_*.go")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write(b)
+	require.NoError(t, err)
+	return f.Name()
+}
+
 func assertFilesEqual(t *testing.T, filename1, filename2 string) {
 	b1, err := ioutil.ReadFile(filename1)
 	assert.NoError(t, err)
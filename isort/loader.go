@@ -0,0 +1,42 @@
+package isort
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageFiles returns all the Go files in the packages matching the given patterns (e.g. ./...),
+// including their tests. Unlike walking the filesystem, this only includes files that are
+// actually part of a package for the current build configuration, so it honours build tags;
+// buildFlags are passed through to the build system and can be used to set them (e.g. -tags=foo).
+// Patterns are resolved relative to dir, or the current directory if it's empty.
+func PackageFiles(dir string, patterns []string, buildFlags []string) ([]string, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles,
+		Dir:        dir,
+		BuildFlags: buildFlags,
+		Tests:      true,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	// Test variants of a package repeat its files, so we need to dedupe them.
+	seen := map[string]bool{}
+	var files []string
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("failed to load %s: %s", pkg.PkgPath, pkg.Errors[0])
+		} else if strings.HasSuffix(pkg.ID, ".test") {
+			continue // This is the generated test main package, which isn't a real source file.
+		}
+		for _, file := range pkg.GoFiles {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,52 @@
+//go:build integration
+// +build integration
+
+// This test needs the go tool to be available to load packages, so it's not run by default.
+
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goisort")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for name, contents := range map[string]string{
+		"go.mod":           "module example.com/test\n",
+		"a.go":             "package test\n",
+		"a_test.go":        "package test\n",
+		"tagged.go":        "// +build foo\n\npackage test\n",
+		"sub/b.go":         "package sub\n",
+		"testdata/c.go":    "package testdata\n",
+		"_ignored/d.go":    "package ignored\n",
+		"sub/generated.go": "// Code generated by hand. DO NOT EDIT.\n\npackage sub\n",
+	} {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, ioutil.WriteFile(filename, []byte(contents), 0644))
+	}
+	// The temp dir might be behind a symlink, which go list will resolve.
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	files, err := PackageFiles(dir, []string{"./..."}, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "a.go"),
+		filepath.Join(dir, "a_test.go"),
+		filepath.Join(dir, "sub/b.go"),
+		filepath.Join(dir, "sub/generated.go"),
+	}, files)
+
+	files, err = PackageFiles(dir, []string{"./..."}, []string{"-tags=foo"})
+	require.NoError(t, err)
+	assert.Contains(t, files, filepath.Join(dir, "tagged.go"))
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+// +build !unix
+
+package isort
+
+import "os"
+
+// copyOwner does nothing on this platform, where files don't have owners in the same sense.
+func copyOwner(path string, info os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build unix
+// +build unix
+
+package isort
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner gives the file at path the same owner and group as the one described by info,
+// if it doesn't already have them. This generally needs privileges to do.
+func copyOwner(path string, info os.FileInfo) error {
+	want, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	current, err := os.Lstat(path)
+	if err != nil {
+		return err
+	} else if have, ok := current.Sys().(*syscall.Stat_t); ok && have.Uid == want.Uid && have.Gid == want.Gid {
+		return nil
+	}
+	return os.Chown(path, int(want.Uid), int(want.Gid))
+}
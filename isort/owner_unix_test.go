@@ -0,0 +1,31 @@
+//go:build unix
+// +build unix
+
+package isort
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewritePreservesOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing a file's owner needs root")
+	}
+	filename := copyToTemp(t, "isort/test_data/test2.go")
+	defer os.Remove(filename)
+	require.NoError(t, os.Chown(filename, 1234, 5678))
+	changes, err := Reformat(filename, Options{})
+	require.NoError(t, err)
+	require.NoError(t, Rewrite(filename, filename, changes))
+	assertFilesEqual(t, "isort/test_data/test2_reformatted.go", filename)
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	st := info.Sys().(*syscall.Stat_t)
+	assert.EqualValues(t, 1234, st.Uid)
+	assert.EqualValues(t, 5678, st.Gid)
+}
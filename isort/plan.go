@@ -0,0 +1,52 @@
+package isort
+
+import "strconv"
+
+// A Plan describes the imports a file would be left with after reformatting, suitable for
+// serialising as JSON so that editors and other tools can preview the changes.
+type Plan struct {
+	Filename  string       `json:"filename"`
+	StartLine int          `json:"start_line"` // Line that imports begin on, 1-indexed.
+	EndLine   int          `json:"end_line"`   // Line that imports end on
+	Needed    bool         `json:"needed"`     // True if changes are needed to this file.
+	Imports   []PlanImport `json:"imports"`    // Imports in the order they'd be written.
+}
+
+// A PlanImport is a single import in a Plan.
+type PlanImport struct {
+	Path    string   `json:"path"` // The import path, unquoted.
+	Name    string   `json:"name,omitempty"`
+	Doc     []string `json:"doc,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+	Group   int      `json:"group"` // Index of the blank-line separated group it's in, from 0.
+}
+
+// NewPlan returns a Plan describing the given changes.
+func NewPlan(changes *Changes) *Plan {
+	plan := &Plan{
+		Filename:  changes.Filename,
+		StartLine: changes.StartLine,
+		EndLine:   changes.EndLine,
+		Needed:    changes.Needed,
+		Imports:   make([]PlanImport, 0, len(changes.Imports)),
+	}
+	group := 0
+	for _, imp := range changes.Imports {
+		if imp.Path == "" {
+			group++
+			continue
+		}
+		path, err := strconv.Unquote(imp.Path)
+		if err != nil {
+			path = imp.Path
+		}
+		plan.Imports = append(plan.Imports, PlanImport{
+			Path:    path,
+			Name:    imp.Name,
+			Doc:     imp.Doc,
+			Comment: imp.Comment,
+			Group:   group,
+		})
+	}
+	return plan
+}
@@ -0,0 +1,33 @@
+package isort
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	changes, err := Reformat("isort/test_data/test2.go", Options{})
+	require.NoError(t, err)
+	plan := NewPlan(changes)
+	assert.Equal(t, "isort/test_data/test2.go", plan.Filename)
+	assert.True(t, plan.Needed)
+	b, err := json.Marshal(plan)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"filename": "isort/test_data/test2.go",
+		"start_line": 4,
+		"end_line": 9,
+		"needed": true,
+		"imports": [
+			{"path": "fmt", "group": 0},
+			{"path": "os", "group": 0},
+			{"path": "path", "group": 0},
+			{"path": "strings", "group": 0},
+			{"path": "github.com/jessevdk/go-flags", "group": 1},
+			{"path": "gopkg.in/op/go-logging.v1", "group": 1}
+		]
+	}`, string(b))
+}
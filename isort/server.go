@@ -0,0 +1,54 @@
+package isort
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// A ServerRequest is a single request to a server to format a file.
+type ServerRequest struct {
+	Filename string `json:"filename"` // Name of the file; used to determine options and in error messages.
+	Content  string `json:"content"`  // Full contents of the file.
+}
+
+// A ServerResponse is the server's response to a single ServerRequest.
+type ServerResponse struct {
+	Content string `json:"content,omitempty"` // Reformatted contents of the file.
+	Changed bool   `json:"changed"`           // True if the contents differ from those in the request.
+	Error   string `json:"error,omitempty"`   // Error message if the file couldn't be formatted.
+}
+
+// Serve runs a long-lived server reading formatting requests from r and writing responses to w.
+//
+// The protocol is newline-delimited JSON; each request is a single ServerRequest object,
+// and for each one the server writes exactly one ServerResponse object followed by a newline,
+// in the same order as the requests. A request that fails to format gets a response with
+// Error set and does not stop the server; it only returns when r is exhausted (returning nil)
+// or it fails to read or write (returning the error).
+//
+// The given function is called for each request to determine the options to format it with.
+// If it returns an error, that request gets a response with Error set.
+func Serve(r io.Reader, w io.Writer, options func(filename string) (Options, error)) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	for {
+		var req ServerRequest
+		if err := dec.Decode(&req); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var resp ServerResponse
+		if opts, err := options(req.Filename); err != nil {
+			resp.Error = err.Error()
+		} else if out, changed, err := Format([]byte(req.Content), req.Filename, opts); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Content = string(out)
+			resp.Changed = changed
+		}
+		if err := enc.Encode(&resp); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package isort
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	unsorted, err := ioutil.ReadFile("isort/test_data/test2.go")
+	require.NoError(t, err)
+	sorted, err := ioutil.ReadFile("isort/test_data/test2_reformatted.go")
+	require.NoError(t, err)
+
+	var in, out bytes.Buffer
+	enc := json.NewEncoder(&in)
+	require.NoError(t, enc.Encode(ServerRequest{Filename: "test2.go", Content: string(unsorted)}))
+	require.NoError(t, enc.Encode(ServerRequest{Filename: "test1.go", Content: string(sorted)}))
+	require.NoError(t, enc.Encode(ServerRequest{Filename: "broken.go", Content: "package"}))
+	filenames := []string{}
+	err = Serve(&in, &out, func(filename string) (Options, error) {
+		filenames = append(filenames, filename)
+		return Options{}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test2.go", "test1.go", "broken.go"}, filenames)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Equal(t, 3, len(lines))
+	var resp ServerResponse
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &resp))
+	assert.Equal(t, ServerResponse{Content: string(sorted), Changed: true}, resp)
+	resp = ServerResponse{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &resp))
+	assert.Equal(t, ServerResponse{Content: string(sorted)}, resp)
+	resp = ServerResponse{}
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &resp))
+	assert.False(t, resp.Changed)
+	assert.Contains(t, resp.Error, "broken.go")
+}
+
+func TestServeOptionsError(t *testing.T) {
+	var in, out bytes.Buffer
+	require.NoError(t, json.NewEncoder(&in).Encode(ServerRequest{Filename: "test1.go", Content: "package core\n"}))
+	err := Serve(&in, &out, func(filename string) (Options, error) {
+		return Options{}, fmt.Errorf("bad config for %s", filename)
+	})
+	require.NoError(t, err)
+	var resp ServerResponse
+	require.NoError(t, json.Unmarshal(out.Bytes(), &resp))
+	assert.Equal(t, ServerResponse{Error: "bad config for test1.go"}, resp)
+}
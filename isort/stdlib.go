@@ -0,0 +1,56 @@
+package isort
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The generated stdlib list reflects whatever version of Go it was last generated with, which
+// lags behind the current release. These are the packages added to the standard library since
+// then, along with the minor version of Go that they appeared in.
+var stdlibAdded = map[string]int{
+	"crypto/ed25519":      13,
+	"hash/maphash":        14,
+	"time/tzdata":         15,
+	"embed":               16,
+	"go/build/constraint": 16,
+	"io/fs":               16,
+	"runtime/metrics":     16,
+	"testing/fstest":      16,
+	"debug/buildinfo":     18,
+	"net/netip":           18,
+	"go/doc/comment":      19,
+	"crypto/ecdh":         20,
+	"cmp":                 21,
+	"log/slog":            21,
+	"maps":                21,
+	"slices":              21,
+	"testing/slogtest":    21,
+	"go/version":          22,
+	"math/rand/v2":        22,
+	"iter":                23,
+	"structs":             23,
+	"unique":              23,
+	"crypto/fips140":      24,
+	"crypto/hkdf":         24,
+	"crypto/mlkem":        24,
+	"crypto/pbkdf2":       24,
+	"crypto/sha3":         24,
+	"weak":                24,
+	"testing/synctest":    25,
+}
+
+// ParseGoVersion parses a Go version such as "1.21", "1.21.3" or "go1.21" and returns its
+// minor version (21 in all those cases), as used by Options.GoVersion.
+func ParseGoVersion(version string) (int, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "go"), ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return 0, fmt.Errorf("invalid Go version %q", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor < 0 {
+		return 0, fmt.Errorf("invalid Go version %q", version)
+	}
+	return minor, nil
+}
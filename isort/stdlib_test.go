@@ -0,0 +1,17 @@
+package isort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	for _, v := range []string{"1.21", "1.21.3", "go1.21"} {
+		minor, err := ParseGoVersion(v)
+		assert.NoError(t, err)
+		assert.Equal(t, 21, minor)
+	}
+	_, err := ParseGoVersion("21")
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package isort
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A Summary summarises the results of checking a set of files, suitable for
+// serialising as JSON for CI gates to consume.
+type Summary struct {
+	Changed int      `json:"changed"` // Number of files needing changes
+	Total   int      `json:"total"`   // Total number of files checked
+	Files   []string `json:"files"`   // Names of the files needing changes (or all of them), in the order they were added.
+	all     bool
+	dirs    map[string]*dirSummary
+}
+
+// A dirSummary counts the files in a directory, including any subdirectories.
+type dirSummary struct {
+	changed, total int
+}
+
+// NewSummary returns a new, empty, Summary.
+// If all is true, its Files contain every file added, not just those needing changes.
+func NewSummary(all bool) *Summary {
+	return &Summary{Files: []string{}, all: all, dirs: map[string]*dirSummary{}}
+}
+
+// Add adds the results of checking a single file to this summary.
+func (s *Summary) Add(filename string, changes *Changes) {
+	s.Total++
+	if changes.Needed {
+		s.Changed++
+	}
+	if changes.Needed || s.all {
+		s.Files = append(s.Files, filename)
+	}
+	for dir := filepath.Dir(filename); ; dir = filepath.Dir(dir) {
+		d := s.dirs[dir]
+		if d == nil {
+			d = &dirSummary{}
+			s.dirs[dir] = d
+		}
+		d.total++
+		if changes.Needed {
+			d.changed++
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+}
+
+// WriteTree writes a tree of the directories containing the files in this summary to the given
+// writer, with the number of files needing changes and the total number of files in each one
+// (including their subdirectories). It starts at the deepest directory containing all the files.
+func (s *Summary) WriteTree(w io.Writer) error {
+	type entry struct {
+		dir      string
+		elements []string
+	}
+	dirs := make([]entry, 0, len(s.dirs))
+	for dir := range s.dirs {
+		dirs = append(dirs, entry{dir: dir, elements: pathElements(dir)})
+	}
+	// Sort by path element, so each directory comes immediately before its subdirectories.
+	sort.Slice(dirs, func(i, j int) bool {
+		a, b := dirs[i].elements, dirs[j].elements
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	// Every directory is an ancestor of the deepest one containing all the files, or a
+	// descendant of it, so once we've found that we can print everything after it.
+	root := 0
+	for i, dir := range dirs {
+		if s.dirs[dir.dir].total == s.Total {
+			root = i
+		}
+	}
+	for _, dir := range dirs[root:] {
+		depth := len(dir.elements) - len(dirs[root].elements)
+		name := dir.dir
+		if depth > 0 {
+			name = dir.elements[len(dir.elements)-1]
+		}
+		d := s.dirs[dir.dir]
+		if _, err := fmt.Fprintf(w, "%s%s: %d/%d\n", strings.Repeat("  ", depth), name, d.changed, d.total); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathElements splits a directory into its elements, so its depth is the number of them.
+// The current directory has none, since it's the parent of any other relative path, and so
+// does the root.
+func pathElements(dir string) []string {
+	if dir == "." || dir == string(filepath.Separator) {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(dir, string(filepath.Separator)), string(filepath.Separator))
+}
@@ -0,0 +1,93 @@
+package isort
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	summary := NewSummary(false)
+	for _, filename := range []string{
+		"isort/test_data/test1.go",
+		"isort/test_data/test2.go",
+		"isort/test_data/test2_reformatted.go",
+		"isort/test_data/blank_lines.go",
+	} {
+		changes, err := Reformat(filename, Options{})
+		require.NoError(t, err)
+		summary.Add(filename, changes)
+	}
+	b, err := json.Marshal(summary)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"changed": 2,
+		"total": 4,
+		"files": ["isort/test_data/test2.go", "isort/test_data/blank_lines.go"]
+	}`, string(b))
+}
+
+func TestSummaryAll(t *testing.T) {
+	summary := NewSummary(true)
+	summary.Add("test1.go", &Changes{})
+	summary.Add("test2.go", &Changes{Needed: true})
+	assert.Equal(t, 1, summary.Changed)
+	assert.Equal(t, 2, summary.Total)
+	assert.Equal(t, []string{"test1.go", "test2.go"}, summary.Files)
+}
+
+func TestEmptySummary(t *testing.T) {
+	b, err := json.Marshal(NewSummary(false))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"changed": 0, "total": 0, "files": []}`, string(b))
+}
+
+func TestSummaryTree(t *testing.T) {
+	summary := NewSummary(false)
+	summary.Add("src/a/one.go", &Changes{Needed: true})
+	summary.Add("src/a/two.go", &Changes{})
+	summary.Add("src/a/b/three.go", &Changes{Needed: true})
+	summary.Add("src/c/four.go", &Changes{})
+	summary.Add("src/five.go", &Changes{Needed: true})
+	var buf bytes.Buffer
+	require.NoError(t, summary.WriteTree(&buf))
+	assert.Equal(t, `src: 3/5
+  a: 2/3
+    b: 1/1
+  c: 0/1
+`, buf.String())
+}
+
+func TestSummaryTreeRelative(t *testing.T) {
+	// Files in the current directory make it the root, and everything else is beneath it.
+	summary := NewSummary(false)
+	summary.Add("main.go", &Changes{Needed: true})
+	summary.Add("isort/isort.go", &Changes{Needed: true})
+	summary.Add("isort/test_data/test1.go", &Changes{})
+	summary.Add("isort/test_data/golden/a.go", &Changes{Needed: true})
+	summary.Add("cmd/x.go", &Changes{})
+	var buf bytes.Buffer
+	require.NoError(t, summary.WriteTree(&buf))
+	assert.Equal(t, `.: 3/5
+  cmd: 0/1
+  isort: 2/3
+    test_data: 1/2
+      golden: 1/1
+`, buf.String())
+
+	summary = NewSummary(false)
+	summary.Add("/a.go", &Changes{})
+	summary.Add("/src/b.go", &Changes{Needed: true})
+	buf.Reset()
+	require.NoError(t, summary.WriteTree(&buf))
+	assert.Equal(t, "/: 1/2\n  src: 1/1\n", buf.String())
+}
+
+func TestEmptySummaryTree(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewSummary(false).WriteTree(&buf))
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,10 @@
+package core
+
+import (
+	"fmt"
+
+	pb "github.com/golang/protobuf/proto"
+	flags "github.com/jessevdk/go-flags"
+)
+
+var x = pb.Marshal
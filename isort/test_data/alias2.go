@@ -0,0 +1,11 @@
+package core
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	flags "github.com/jessevdk/go-flags"
+)
+
+var x = proto.Marshal
@@ -0,0 +1,11 @@
+package core
+
+import (
+	"os"
+	// line one
+	//
+	// line two
+	"fmt"
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,11 @@
+package core
+
+import (
+	// line one
+	//
+	// line two
+	"fmt"
+	"os"
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,12 @@
+package core
+
+import (
+	_ "github.com/lib/pq"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/ginkgo"
+	_ "embed"
+	"fmt"
+	"os"
+	pb "github.com/golang/protobuf/proto"
+	types "github.com/onsi/gomega/types"
+)
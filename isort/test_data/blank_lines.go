@@ -0,0 +1,15 @@
+package core
+
+import (
+	"fmt"
+
+	"os"
+
+
+
+	"github.com/jessevdk/go-flags"
+
+	"gopkg.in/op/go-logging.v1"
+)
+
+var log = logging.MustGetLogger("core")
@@ -0,0 +1,13 @@
+//go:build linux
+// +build linux
+
+// Package core has a build constraint.
+package core
+
+
+import (
+	"os"
+	"fmt"
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,9 @@
+package core
+
+import (
+	"github.com/azure/y"
+	"github.com/Beta/b"
+	"github.com/aws/z"
+	"github.com/Azure/x"
+	"github.com/azure/x"
+)
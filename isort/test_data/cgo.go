@@ -0,0 +1,16 @@
+package core
+
+// #include <stdlib.h>
+// #include <stdio.h>
+import "C"
+
+import (
+	"unsafe"
+	"os"
+	"github.com/jessevdk/go-flags"
+	"fmt"
+)
+
+func free(p unsafe.Pointer) {
+	C.free(p)
+}
@@ -0,0 +1,17 @@
+package core
+
+// #include <stdlib.h>
+// #include <stdio.h>
+import "C"
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/jessevdk/go-flags"
+)
+
+func free(p unsafe.Pointer) {
+	C.free(p)
+}
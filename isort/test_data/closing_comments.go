@@ -0,0 +1,12 @@
+package core
+
+import (
+	"os"
+	"fmt" /* a */; "bytes"
+	"io"
+	// "strings"
+
+	// TODO: add more
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,13 @@
+package core
+
+import (
+	"bytes"
+	"fmt" /* a */
+	"io"
+	"os"
+	// "strings"
+
+	// TODO: add more
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,14 @@
+package core
+
+import (
+	// Our own packages, which we like to see first.
+	"github.com/peterebden/goisort/isort"
+	"github.com/peterebden/goisort/cache"
+
+	// Everything else.
+	"os"
+	"github.com/jessevdk/go-flags"
+	"fmt"
+)
+
+var log = isort.Reformat
@@ -0,0 +1,15 @@
+package core
+
+import (
+	// Our own packages, which we like to see first.
+	"github.com/peterebden/goisort/cache"
+	"github.com/peterebden/goisort/isort"
+
+	// Everything else.
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var log = isort.Reformat
@@ -0,0 +1,8 @@
+package core
+
+import (
+	"fmt"    // for printing
+	"os"
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,8 @@
+package core
+
+import (
+	"os"
+	"fmt"
+
+	// "strings"
+)
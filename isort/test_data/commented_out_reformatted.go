@@ -0,0 +1,8 @@
+package core
+
+import (
+	"fmt"
+	"os"
+
+	// "strings"
+)
@@ -0,0 +1,14 @@
+// Copyright 2019 The goisort Authors.
+
+package core
+
+import (
+	"os"
+	"github.com/jessevdk/go-flags"
+	"fmt"
+)
+
+// Main does things.
+func Main() {
+	fmt.Println(os.Args, flags.Default)
+}
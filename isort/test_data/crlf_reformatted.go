@@ -0,0 +1,15 @@
+// Copyright 2019 The goisort Authors.
+
+package core
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// Main does things.
+func Main() {
+	fmt.Println(os.Args, flags.Default)
+}
@@ -0,0 +1,10 @@
+package core
+
+import (
+	bar2 "github.com/foo/bar"
+	"fmt"
+	"github.com/foo/bar"
+	abar "github.com/foo/bar"
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,12 @@
+package core
+
+import (
+	"os"
+	// Formatting
+	"fmt"
+	"github.com/foo/bar"
+	"fmt" // merged in twice
+	baz "github.com/foo/bar"
+)
+
+var x = fmt.Sprintf
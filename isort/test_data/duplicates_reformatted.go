@@ -0,0 +1,12 @@
+package core
+
+import (
+	// Formatting
+	"fmt" // merged in twice
+	"os"
+
+	"github.com/foo/bar"
+	baz "github.com/foo/bar"
+)
+
+var x = fmt.Sprintf
@@ -0,0 +1,8 @@
+package core
+
+import ()
+
+import (
+)
+
+var x = 1
@@ -0,0 +1,13 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/peterebden/ctxpoly"
+)
+
+var log = ctxpoly.Background()
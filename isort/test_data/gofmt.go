@@ -0,0 +1,11 @@
+package core
+
+import (
+    "os"   // trailing comments that gofmt aligns
+	flags   "github.com/jessevdk/go-flags" // a long one
+	"fmt" /* block */
+    // about bytes
+    "bytes"
+)
+
+var  x = fmt.Sprintf
@@ -0,0 +1,8 @@
+package core
+
+import (
+	// Our own code.
+	"github.com/peterebden/goisort/isort"
+	"github.com/jessevdk/go-flags"
+	"fmt"
+)
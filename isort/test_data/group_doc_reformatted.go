@@ -0,0 +1,10 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	// Our own code.
+	"github.com/peterebden/goisort/isort"
+)
@@ -0,0 +1,9 @@
+package core
+
+import (
+	"golang.org/x/tools"
+	"example.com/a"
+	"Example.com/b"
+	"example.com/B"
+	"example.com/A"
+)
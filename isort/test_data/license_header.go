@@ -0,0 +1,18 @@
+/*
+ * Copyright 2024 The goisort Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ */
+
+package main
+
+import (
+	"os"
+	"github.com/jessevdk/go-flags"
+	"fmt"
+)
+
+func main() {
+	fmt.Println(os.Args, flags.Default)
+}
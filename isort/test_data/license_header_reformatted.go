@@ -0,0 +1,19 @@
+/*
+ * Copyright 2024 The goisort Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+)
+
+func main() {
+	fmt.Println(os.Args, flags.Default)
+}
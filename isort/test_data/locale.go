@@ -0,0 +1,12 @@
+package core
+
+import (
+	"github.com/zeta/pkg"
+	"github.com/Zeta/pkg"
+	"github.com/ıstanbul/pkg"
+	"github.com/istanbul/pkg"
+	"github.com/İstanbul/pkg"
+	"github.com/Istanbul/pkg"
+	"github.com/éclair/pkg"
+	"github.com/eclair/pkg"
+)
@@ -0,0 +1,11 @@
+package core
+
+import "github.com/jessevdk/go-flags"
+
+// Some more imports.
+import (
+	"os"
+	"fmt"
+)
+
+var x = fmt.Sprintf
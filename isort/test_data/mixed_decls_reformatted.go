@@ -0,0 +1,11 @@
+package core
+
+import (
+	"fmt"
+	// Some more imports.
+	"os"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var x = fmt.Sprintf
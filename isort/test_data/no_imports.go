@@ -0,0 +1,3 @@
+package core
+
+var x = 1
@@ -0,0 +1,15 @@
+package core
+
+import (
+	"os"
+	//nolint:depguard
+	"github.com/b/c"
+	"fmt" //nolint:gci
+	//lint:ignore SA1019 deprecated
+	"bytes"
+
+	//nolint:all
+
+	"io"
+	"errors" /* nolint */ //nolint:x
+)
@@ -0,0 +1,14 @@
+package core
+
+import (
+	//lint:ignore SA1019 deprecated
+	"bytes"
+	"errors" /* nolint */ //nolint:x
+	"fmt"    //nolint:gci
+	//nolint:all
+	"io"
+	"os"
+
+	//nolint:depguard
+	"github.com/b/c"
+)
@@ -0,0 +1,19 @@
+// Copyright 2019 The goisort Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+// +build ignore
+
+// Command gen generates things.
+package main
+import (
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+)
+
+func main() {
+	fmt.Println(os.Args, flags.Default)
+}
@@ -0,0 +1,12 @@
+package core
+
+import (
+	"fmt"
+	"github.com/peterebden/goisort/proto/isort.pb"
+	"github.com/peterebden/goisort/isort"
+	"github.com/jessevdk/go-flags"
+	"github.com/peterebden/goisort/protobuf"
+	"os"
+)
+
+var x = fmt.Sprintf
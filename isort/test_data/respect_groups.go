@@ -0,0 +1,15 @@
+package core
+
+import (
+	"github.com/peterebden/goisort/isort"
+	"os"
+	"github.com/jessevdk/go-flags"
+
+	"strings"
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+	"bytes"
+)
+
+var log = isort.Reformat
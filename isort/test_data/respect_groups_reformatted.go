@@ -0,0 +1,15 @@
+package core
+
+import (
+	"github.com/jessevdk/go-flags"
+	"github.com/peterebden/goisort/isort"
+	"os"
+
+	"fmt"
+	"strings"
+
+	"bytes"
+	"github.com/stretchr/testify/assert"
+)
+
+var log = isort.Reformat
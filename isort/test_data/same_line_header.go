@@ -0,0 +1,8 @@
+/* Licensed under the MIT license. */ package main; import (
+	"os"
+	"fmt"
+)
+
+func main() {
+	fmt.Println(os.Args)
+}
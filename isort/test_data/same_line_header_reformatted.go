@@ -0,0 +1,8 @@
+/* Licensed under the MIT license. */ package main; import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Println(os.Args)
+}
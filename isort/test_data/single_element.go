@@ -0,0 +1,10 @@
+package core
+
+import (
+	"fmt"
+
+	"example"
+	"example.com/foo"
+)
+
+var x = fmt.Sprintf
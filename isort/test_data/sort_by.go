@@ -0,0 +1,13 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	jflags "github.com/jessevdk/go-flags"
+	"github.com/peterebden/goisort/v2"
+)
+
+var x = fmt.Sprintf
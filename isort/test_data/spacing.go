@@ -0,0 +1,14 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"github.com/jessevdk/go-flags"
+
+
+	"gopkg.in/op/go-logging.v1"
+)
+
+var log = logging.MustGetLogger("core")
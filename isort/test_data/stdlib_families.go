@@ -0,0 +1,12 @@
+package core
+
+import (
+	"net/url"
+	"encoding/json"
+	"fmt"
+	"net"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/jessevdk/go-flags"
+)
@@ -0,0 +1,14 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"fmt"
+
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/jessevdk/go-flags"
+)
@@ -0,0 +1,7 @@
+package core
+import (
+	"os"
+	"fmt"
+)
+
+var x = fmt.Sprintf
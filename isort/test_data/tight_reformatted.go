@@ -0,0 +1,7 @@
+package core
+import (
+	"fmt"
+	"os"
+)
+
+var x = fmt.Sprintf
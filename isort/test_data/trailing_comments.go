@@ -0,0 +1,9 @@
+package core
+
+import (
+	"os" // plain comment
+	"github.com/foo/bar" //nolint:depguard
+	"unsafe" //go:linkname is used below
+	"fmt" /* block */ // and line
+	"bytes"
+)
@@ -0,0 +1,10 @@
+package core
+
+import (
+	"bytes"
+	"fmt"    /* block */ // and line
+	"os"     // plain comment
+	"unsafe" //go:linkname is used below
+
+	"github.com/foo/bar" //nolint:depguard
+)
@@ -0,0 +1,13 @@
+package core
+
+import (
+	"fmt"
+	"os"
+)
+
+import (
+	"github.com/jessevdk/go-flags"
+	"bytes"
+)
+
+var x = fmt.Sprintf
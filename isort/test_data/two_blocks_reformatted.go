@@ -0,0 +1,11 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var x = fmt.Sprintf
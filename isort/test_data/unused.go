@@ -0,0 +1,13 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	_ "net/http/pprof"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Println is documented here but fmt isn't really used.
+func main() {
+	os.Exit(yaml.Marshal())
+}
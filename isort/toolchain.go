@@ -0,0 +1,114 @@
+package isort
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var toolchainOnce sync.Once
+var toolchainPkgs []string
+var toolchainErr error
+
+// ToolchainStdlib returns the standard library packages of the Go toolchain on the PATH, as
+// listed by `go list std`, which can be passed as Options.ExtraStd so that packages added in
+// newer versions of Go than we know about are still classified correctly.
+// go list is fairly slow, so the result is cached under the user's cache directory (keyed by
+// the toolchain's version and GOROOT), and only looked up once per process.
+// It returns an error if there's no go toolchain available.
+func ToolchainStdlib() ([]string, error) {
+	toolchainOnce.Do(func() {
+		dir, err := os.UserCacheDir()
+		if err == nil {
+			dir = filepath.Join(dir, "goisort")
+		} else {
+			dir = "" // Just don't cache it.
+		}
+		toolchainPkgs, toolchainErr = loadToolchainStdlib(dir)
+	})
+	return toolchainPkgs, toolchainErr
+}
+
+// loadToolchainStdlib implements ToolchainStdlib, caching the result in the given directory
+// if it's not empty.
+func loadToolchainStdlib(cacheDir string) ([]string, error) {
+	env, err := exec.Command("go", "env", "GOVERSION", "GOROOT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find go toolchain: %s", err)
+	}
+	cacheFile := ""
+	if cacheDir != "" {
+		cacheFile = filepath.Join(cacheDir, fmt.Sprintf("stdlib_%x", sha256.Sum256(env)))
+		if b, err := ioutil.ReadFile(cacheFile); err == nil {
+			return strings.Fields(string(b)), nil
+		}
+	}
+	out, err := exec.Command("go", "list", "std").Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list standard library packages: %s", err)
+	}
+	var pkgs []string
+	for _, pkg := range strings.Fields(string(out)) {
+		// These can't be imported from outside the standard library; this matches what we
+		// exclude when generating the built-in list.
+		if !strings.HasPrefix(pkg, "vendor/") && !strings.Contains(pkg, "internal/") {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if cacheFile != "" {
+		// Failing to cache it isn't a problem, we'll just have to do this again next time.
+		if err := os.MkdirAll(cacheDir, 0755); err == nil {
+			ioutil.WriteFile(cacheFile, []byte(strings.Join(pkgs, "\n")+"\n"), 0644)
+		}
+	}
+	return pkgs, nil
+}
+
+var gorootMutex sync.Mutex
+var gorootPkgs = map[string][]string{}
+
+// gorootStdlib returns the standard library packages found under the given GOROOT, in the same
+// way as the built-in list is generated. It's only walked once per GOROOT.
+// If goroot is empty or can't be read it returns nothing.
+func gorootStdlib(goroot string) []string {
+	if goroot == "" {
+		return nil
+	}
+	gorootMutex.Lock()
+	defer gorootMutex.Unlock()
+	if pkgs, present := gorootPkgs[goroot]; present {
+		return pkgs
+	}
+	src := filepath.Join(goroot, "src")
+	seen := map[string]struct{}{}
+	var pkgs []string
+	filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		} else if d.IsDir() {
+			if name := d.Name(); name == "vendor" || name == "internal" || name == "testdata" || (name == "cmd" && filepath.Dir(path) == src) {
+				return filepath.SkipDir
+			}
+			return nil
+		} else if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			// Directories with only tests in aren't importable packages.
+			if pkg := filepath.ToSlash(filepath.Dir(path[len(src)+1:])); pkg != "." {
+				if _, present := seen[pkg]; !present {
+					seen[pkg] = struct{}{}
+					pkgs = append(pkgs, pkg)
+				}
+			}
+		}
+		return nil
+	})
+	sort.Strings(pkgs)
+	gorootPkgs[goroot] = pkgs
+	return pkgs
+}
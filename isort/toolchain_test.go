@@ -0,0 +1,38 @@
+package isort
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolchainStdlib(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go toolchain available")
+	}
+	dir, err := ioutil.TempDir("", "goisort_toolchain")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pkgs, err := loadToolchainStdlib(dir)
+	require.NoError(t, err)
+	assert.Contains(t, pkgs, "fmt")
+	assert.Contains(t, pkgs, "net/http")
+	assert.NotContains(t, pkgs, "internal/abi")
+	for _, pkg := range pkgs {
+		assert.NotContains(t, pkg, "vendor/")
+	}
+
+	// The second time it should come from the cache.
+	files, err := filepath.Glob(filepath.Join(dir, "stdlib_*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.NoError(t, ioutil.WriteFile(files[0], []byte("fmt\nvery/new/package\n"), 0644))
+	pkgs, err = loadToolchainStdlib(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fmt", "very/new/package"}, pkgs)
+}
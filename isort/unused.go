@@ -0,0 +1,51 @@
+package isort
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+// checkUnused returns warnings for any imports whose name is never referred to in the rest of
+// the file. This is only a heuristic; we don't know the real name of a package without loading
+// it, so we only guess from its path and skip any where that doesn't give a valid identifier.
+// Blank, dot and cgo imports are never reported.
+func checkUnused(fset *token.FileSet, f *ast.File) []Warning {
+	used := map[string]bool{}
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if sel, ok := node.(*ast.SelectorExpr); ok {
+				if ident, ok := sel.X.(*ast.Ident); ok {
+					used[ident.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	var warnings []Warning
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || path == "C" {
+			continue
+		}
+		alias := ""
+		if spec.Name != nil {
+			alias = spec.Name.Name
+		}
+		if alias == "_" || alias == "." {
+			continue
+		}
+		name := importName(alias, path)
+		if !token.IsIdentifier(name) || used[name] {
+			continue
+		}
+		warnings = append(warnings, Warning{
+			Line:    fset.Position(spec.Pos()).Line,
+			Message: "import " + spec.Path.Value + " appears to be unused",
+		})
+	}
+	return warnings
+}
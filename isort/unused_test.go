@@ -0,0 +1,19 @@
+package isort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarnUnused(t *testing.T) {
+	changes, err := Reformat("isort/test_data/unused.go", Options{WarnUnused: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []Warning{{Line: 4, Message: `import "fmt" appears to be unused`}}, changes.Warnings)
+}
+
+func TestWarnUnusedOff(t *testing.T) {
+	changes, err := Reformat("isort/test_data/unused.go", Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Warnings)
+}
@@ -2,38 +2,732 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/jessevdk/go-flags"
 
 	"github.com/peterebden/goisort/isort"
 )
 
-var opts struct {
-	LocalPackage string `long:"local_package" short:"l" description:"Import path of the local package (e.g. github.com/peterebden/goisort"`
-	Write        bool   `long:"write" short:"w" description:"Rewrite the files in-place"`
-	Args         struct {
-		Files []flags.Filename `positional-arg-name:"files" required:"true" description:"Files to sort imports in"`
+type options struct {
+	LocalPackage   []string `long:"local_package" short:"l" env:"GOISORT_LOCAL_PACKAGE" env-delim:"," description:"Import path of the local package (e.g. github.com/peterebden/goisort). Can be repeated or comma-separated to give several. If neither this nor the environment variable are set, it defaults to the module in the nearest go.mod."`
+	ExtraStd       []string `long:"extra-std" description:"Additional import paths to treat as part of the standard library. Can be repeated."`
+	GOROOT         string   `long:"goroot" description:"GOROOT to look for standard library packages in, as well as the built-in list. If there's no go toolchain on the PATH to ask, this defaults to the one goisort was built with."`
+	GoVersion      string   `long:"go-version" description:"Version of Go (e.g. 1.21) to classify standard library packages as of. Defaults to the latest, plus anything the local go toolchain reports as standard."`
+	CommentGroups  bool     `long:"comment-groups" description:"Treat a comment starting a section of the import block as a group header, and only sort within each section"`
+	RespectGroups  bool     `long:"respect-groups" description:"Keep the existing blank-line delimited groups of imports exactly as they are, and only sort within each one"`
+	Groups         []string `long:"group" description:"Group to sort imports into. Each is one of std, thirdparty or local (or several of them joined by +, to share one group), or a comma-separated list of import path prefixes. Can be repeated; groups are written in the order given."`
+	ExtraGroups    []string `long:"extra-groups" description:"Comma-separated list of import path prefixes (e.g. golang.org/x/) to put in a group of their own straight after the standard library. Can be repeated to add several groups."`
+	GroupSet       string   `long:"groups" description:"Groups to separate imports into, as a comma-separated list of std, thirdparty and local, where any joined with + share a group (e.g. std+thirdparty,local). 2 is short for that, and 3 for the default std,thirdparty,local. Can't be combined with --group."`
+	GroupRules     []string `long:"group-rule" description:"Rule of the form pattern=group assigning imports to a named group. Can be repeated; the first matching rule wins, and groups are written in the order they're first named. Replaces --group if given."`
+	AlwaysParens   bool     `long:"always-parens" description:"Always write imports in a parenthesised block, even if there's only one"`
+	GroupSpacing   int      `long:"group-spacing" default:"1" description:"Number of blank lines to separate groups of imports by"`
+	DefaultGroup   string   `long:"default-group" description:"Group for imports that don't match any --group-rule. By default they go at the end."`
+	SortBy         string   `long:"sort-by" choice:"path" choice:"name" default:"path" description:"Sort imports within each group by their full path, or by the name they're imported as"`
+	StdlibFamilies bool     `long:"stdlib-families" description:"Separate standard library imports by the first element of their path (e.g. all of net/... together) with blank lines between each"`
+	CaseFold       bool     `long:"case-insensitive" description:"Compare import paths case-insensitively when sorting, rather than byte-wise as gofmt and goimports do"`
+	BlankLast      bool     `long:"group-underscore" description:"Sort dot imports after the others in each group, and then blank (_) imports after those. Imports with other aliases are still sorted by path along with unaliased ones."`
+	HostFold       bool     `long:"host-case-insensitive" description:"Compare the host part of import paths (e.g. github.com) case-insensitively when sorting. The rest of each path is still compared case-sensitively."`
+	AliasOrder     string   `long:"alias-order" choice:"unaliased-first" choice:"aliased-first" default:"unaliased-first" description:"Whether aliased imports sort before or after unaliased ones of the same path"`
+	TieBreak       string   `long:"tie-break" choice:"name" choice:"original" default:"name" description:"Whether aliased imports of the same path are sorted by their alias, or kept in their original order"`
+	MaxUngrouped   int      `long:"max-ungrouped" description:"Files with at most this many imports are sorted alphabetically as a single group, without blank lines between groups. Files with more are grouped as usual. This overrides --group, --groups, --group-rule, --extra-groups and --stdlib-families for small files, but sections kept apart by --comment-groups or --respect-groups still are, with --group-spacing blank lines between them."`
+	WarnIndent     bool     `long:"warn-indent" description:"Warn about import blocks that aren't indented with tabs"`
+	WarnUnused     bool     `long:"warn-unused" description:"Warn about imports that look like they're never used. This is only a heuristic, and they are never removed."`
+	FinalNewline   string   `long:"final-newline" choice:"editorconfig" choice:"insert" choice:"preserve" default:"editorconfig" description:"Whether to ensure rewritten files end in a newline. By default this follows insert_final_newline in .editorconfig, and otherwise preserves whatever was there."`
+	Verify         bool     `long:"verify" description:"Check that each reformatted file still parses before writing it"`
+	IgnoreComments bool     `long:"ignore-comment-whitespace" description:"Don't count files as needing changes if the only difference is the spacing before trailing comments on imports"`
+	Gofmt          bool     `long:"gofmt" description:"Run reformatted files through gofmt, so the import block is guaranteed to match its formatting. This also formats the rest of each file, and is slower."`
+	ExitBitmask    bool     `long:"exit-bitmask" description:"Make the exit code a bitmask of what happened: 1 if any files need (or had) changes, 2 if any couldn't be read or parsed, and 4 if any import something given to --deny. Files that fail to parse don't stop the others being processed."`
+	Deny           []string `long:"deny" description:"Import path that files mustn't import, along with anything beneath it. Can be repeated. Files that do are reported, and the exit status is non-zero."`
+	Generated      bool     `long:"include-generated" description:"Sort imports in generated files too (those with a 'Code generated ... DO NOT EDIT.' comment), which are skipped by default"`
+	Oscillation    bool     `long:"detect-oscillation" description:"Check that formatting each file settles down after a few runs, rather than flipping between two formats, and report any that don't. This is mostly useful for debugging."`
+	Check          string   `long:"check" short:"c" optional:"yes" optional-value:"full" choice:"full" choice:"groups" description:"Print the names of files that need changes, and exit with status 1 if there are any. Files are never modified. With --check=groups, only the order of imports between groups is checked, not within them."`
+	List           bool     `long:"list" description:"Print the names of files that need changes, like gofmt -l. Unlike --check, this always exits 0 if the files could be processed."`
+	Diff           bool     `long:"diff" short:"d" description:"Print a unified diff of the changes needed to each file"`
+	Write          bool     `long:"write" short:"w" description:"Rewrite the files in-place"`
+	Cache          string   `long:"cache" description:"Directory to cache files known to be sorted in, so they are skipped on later runs"`
+	CheckAliases   bool     `long:"check-aliases" description:"Warn about import paths that are aliased inconsistently between files"`
+	JSON           string   `long:"json" optional:"yes" optional-value:"summary" choice:"summary" choice:"changes" description:"When not rewriting, print a JSON summary of the files needing changes, or with --json=changes an array describing the imports each file would be left with"`
+	All            bool     `long:"all" description:"Include files that need no changes in the output, which by default only covers files needing changes"`
+	Jobs           string   `long:"jobs" short:"j" default:"auto" description:"Number of files to process at once, or auto to use one per CPU. If this is more than 1, messages about different files may be printed in any order."`
+	Packages       bool     `long:"packages" description:"Treat the arguments as package patterns (e.g. ./...) and sort imports in all the files in those packages, as determined by go list"`
+	Tags           []string `long:"tags" description:"Build tags to use with --packages when deciding which files are part of a package, as a comma-separated list. Can be repeated."`
+	Report         string   `long:"report" choice:"text" choice:"json" description:"Print a diagnostic to stderr (or the --output file) for each file that needs changes, either as file:line: message or as reviewdog's rdjsonl, and exit with status 1 if there are any"`
+	Summary        bool     `long:"summary" description:"Print a tree of the directories processed, with how many files in each need changes"`
+	CPUProfile     string   `long:"cpuprofile" description:"File to write a CPU profile to"`
+	MemProfile     string   `long:"memprofile" description:"File to write a memory profile to at the end of the run"`
+	Output         string   `long:"output" short:"o" description:"File to write the report to instead of stdout. This includes any --report diagnostics, which otherwise go to stderr."`
+	Server         bool     `long:"server" description:"Run as a server, reading requests to format files from stdin and writing responses to stdout"`
+	Args           struct {
+		Files []flags.Filename `positional-arg-name:"files" description:"Files to sort imports in. Directories are searched recursively for Go files. Glob patterns (including **) are expanded. A single - reads from stdin and writes the result to stdout."`
 	} `positional-args:"true"`
 }
 
 func main() {
-	if _, err := flags.Parse(&opts); err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err)
-		os.Exit(1)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs goisort with the given command-line arguments and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts options
+	if _, err := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash).ParseArgs(args); err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	} else if len(opts.Args.Files) == 0 && !opts.Server {
+		fmt.Fprintf(stderr, "the required argument `files` was not provided\n")
+		return 1
+	} else if opts.Check != "" && opts.Write {
+		fmt.Fprintf(stderr, "--check and --write can't be used together\n")
+		return 1
+	} else if len(opts.Tags) > 0 && !opts.Packages {
+		fmt.Fprintf(stderr, "--tags can only be used with --packages\n")
+		return 1
+	}
+	report := stdout
+	diagnostics := stderr // Where --report goes; this is also redirected by --output.
+	if opts.Output != "" {
+		f, err := createOutput(opts.Output)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to create output file: %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		report = f
+		diagnostics = f
+	}
+	if opts.CPUProfile != "" {
+		f, err := os.Create(opts.CPUProfile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to create CPU profile: %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintf(stderr, "Failed to start CPU profile: %s\n", err)
+			return 1
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if opts.MemProfile != "" {
+		f, err := os.Create(opts.MemProfile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to create memory profile: %s\n", err)
+			return 1
+		}
+		defer func() {
+			runtime.GC() // Make sure the statistics are up to date
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				fmt.Fprintf(stderr, "Failed to write memory profile: %s\n", err)
+			}
+			f.Close()
+		}()
+	}
+	var goVersion int
+	if opts.GoVersion != "" {
+		v, err := isort.ParseGoVersion(opts.GoVersion)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return 1
+		}
+		goVersion = v
+	} else if pkgs, err := isort.ToolchainStdlib(); err == nil {
+		// Pick up anything newer than our built-in list.
+		opts.ExtraStd = append(opts.ExtraStd, pkgs...)
+	} else if opts.GOROOT == "" {
+		// Without a go toolchain, the GOROOT we were built with is the next best thing, if it's still there.
+		opts.GOROOT = runtime.GOROOT()
+	}
+	groups := opts.Groups
+	if opts.GroupSet != "" {
+		if len(groups) > 0 {
+			fmt.Fprintf(stderr, "--groups and --group can't be used together\n")
+			return 1
+		}
+		g, err := parseGroupSet(opts.GroupSet)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return 1
+		}
+		groups = g
+	}
+	// Any groups given as flags take precedence over config files.
+	useConfig := len(groups) == 0 && len(opts.GroupRules) == 0
+	if opts.GroupSpacing < 1 {
+		fmt.Fprintf(stderr, "Invalid --group-spacing %d, must be at least 1\n", opts.GroupSpacing)
+		return 1
+	} else if opts.GroupSpacing > 1 && opts.Gofmt {
+		fmt.Fprintf(stderr, "--group-spacing can't be more than 1 with --gofmt, which would collapse the blank lines again\n")
+		return 1
+	}
+	if len(opts.ExtraGroups) > 0 && len(opts.GroupRules) > 0 {
+		fmt.Fprintf(stderr, "--extra-groups and --group-rule can't be used together\n")
+		return 1
+	}
+	rules := make([]isort.GroupRule, len(opts.GroupRules))
+	for i, rule := range opts.GroupRules {
+		r, err := isort.ParseGroupRule(rule)
+		if err != nil {
+			fmt.Fprintf(stderr, "Invalid --group-rule %s, must be of the form pattern=group\n", rule)
+			return 1
+		}
+		rules[i] = r
+	}
+	baseOpts := isort.Options{
+		LocalPackage:            strings.Join(opts.LocalPackage, ","),
+		ExtraStd:                opts.ExtraStd,
+		GOROOT:                  opts.GOROOT,
+		GoVersion:               goVersion,
+		CommentGroups:           opts.CommentGroups,
+		Groups:                  groups,
+		ExtraGroups:             opts.ExtraGroups,
+		GroupRules:              rules,
+		DefaultGroup:            opts.DefaultGroup,
+		GroupSpacing:            opts.GroupSpacing,
+		AlwaysParens:            opts.AlwaysParens,
+		RespectGroups:           opts.RespectGroups,
+		SortBy:                  isort.SortKey(opts.SortBy),
+		AliasedFirst:            opts.AliasOrder == "aliased-first",
+		HostCaseInsensitive:     opts.HostFold,
+		CaseInsensitive:         opts.CaseFold,
+		BlankImportsLast:        opts.BlankLast,
+		StdlibFamilies:          opts.StdlibFamilies,
+		TieBreak:                isort.TieBreak(opts.TieBreak),
+		MaxUngrouped:            opts.MaxUngrouped,
+		WarnIndent:              opts.WarnIndent,
+		WarnUnused:              opts.WarnUnused,
+		Verify:                  opts.Verify,
+		Gofmt:                   opts.Gofmt,
+		IgnoreCommentWhitespace: opts.IgnoreComments,
+		CheckGroupsOnly:         opts.Check == "groups",
+		FinalNewline:            opts.FinalNewline == "insert",
+	}
+	modules := isort.NewModuleFinder()
+	editorConfig := isort.NewEditorConfig()
+	configs := isort.NewConfigFinder()
+	// optionsFor returns the options to reformat a single file with.
+	optionsFor := func(filename string) (isort.Options, error) {
+		o := baseOpts
+		if useConfig {
+			config, err := configs.Config(filename)
+			if err != nil {
+				return o, fmt.Errorf("Failed to read config: %s", err)
+			}
+			if len(config.Rules) > 0 && len(o.ExtraGroups) > 0 {
+				return o, fmt.Errorf("--extra-groups can't be used together with rules from %s", isort.ConfigFilename)
+			}
+			o.Groups = config.GroupSpecs()
+			o.GroupRules = config.Rules
+		}
+		if o.LocalPackage == "" {
+			o.LocalPackage = modules.Module(filename)
+		}
+		if opts.FinalNewline == "editorconfig" {
+			o.FinalNewline, _ = editorConfig.FinalNewline(filename)
+		}
+		return o, nil
+	}
+	if opts.Server {
+		if err := isort.Serve(stdin, stdout, optionsFor); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return 1
+		}
+		return 0
 	}
 	for _, filename := range opts.Args.Files {
-		changes, err := isort.Reformat(string(filename), opts.LocalPackage)
+		if filename == "-" {
+			if len(opts.Args.Files) > 1 || opts.Write || opts.Check != "" || opts.Diff || opts.List || opts.Report != "" || opts.JSON != "" || len(opts.Deny) > 0 {
+				fmt.Fprintf(stderr, "- (for stdin) can't be used with any other files, or with --write, --check, --diff, --list, --report, --json or --deny\n")
+				return 1
+			}
+			o, err := optionsFor("-")
+			if err != nil {
+				fmt.Fprintf(stderr, "%s\n", err)
+				return 1
+			}
+			return formatStdin(stdin, stdout, stderr, o)
+		}
+	}
+	var cache *isort.Cache
+	var inventory *isort.Inventory
+	if opts.JSON == "changes" && opts.Write {
+		fmt.Fprintf(stderr, "--json=changes can't be used with --write\n")
+		return 1
+	}
+	if opts.CheckAliases {
+		// Every file has to be parsed to check aliases between them, so the cache isn't useful.
+		inventory = isort.NewInventory()
+	} else if opts.Cache != "" && opts.JSON != "changes" && len(opts.Deny) == 0 {
+		// Nor is it with --json=changes or --deny, which need the imports from every file.
+		c, err := isort.NewCache(opts.Cache)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to create cache: %s\n", err)
+			return 1
+		}
+		cache = c
+	}
+	jobs := runtime.GOMAXPROCS(0)
+	if opts.Jobs != "auto" {
+		n, err := strconv.Atoi(opts.Jobs)
+		if err != nil || n < 1 {
+			fmt.Fprintf(stderr, "Invalid value for --jobs: %s\n", opts.Jobs)
+			return 1
+		}
+		jobs = n
+	}
+	var files []string
+	var err error
+	if opts.Packages {
+		patterns := make([]string, len(opts.Args.Files))
+		for i, pattern := range opts.Args.Files {
+			patterns[i] = string(pattern)
+		}
+		var buildFlags []string
+		if len(opts.Tags) > 0 {
+			buildFlags = []string{"-tags=" + strings.Join(opts.Tags, ",")}
+		}
+		files, err = isort.PackageFiles("", patterns, buildFlags)
+	} else {
+		files, err = expandFiles(opts.Args.Files)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to find files: %s\n", err)
+		return 1
+	}
+	// Files are processed concurrently, so make sure messages don't get interleaved.
+	stderr = &syncWriter{w: stderr}
+	results := make([]result, len(files))
+	var wg sync.WaitGroup
+	var stop int32
+	sem := make(chan struct{}, jobs)
+	for i, filename := range files {
+		sem <- struct{}{}
+		if atomic.LoadInt32(&stop) != 0 {
+			break // Something has gone wrong, don't start any more.
+		}
+		wg.Add(1)
+		go func(i int, filename string) {
+			defer func() {
+				if results[i].fatal && !(opts.ExitBitmask && results[i].invalid) {
+					atomic.StoreInt32(&stop, 1)
+				}
+				<-sem
+				wg.Done()
+			}()
+			if !opts.Generated && isort.IsGenerated(filename) {
+				return // It'll only get regenerated, so leave it alone.
+			}
+			o, err := optionsFor(filename)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s\n", err)
+				results[i] = result{fatal: true}
+				return
+			}
+			if opts.Oscillation && !checkStable(filename, o, stderr) {
+				results[i] = result{failed: true}
+				return
+			}
+			results[i] = processFile(filename, o, cache, opts.Write, opts.Diff, stderr)
+		}(i, filename)
+	}
+	wg.Wait()
+	summary := isort.NewSummary(opts.All)
+	plans := []*isort.Plan{}
+	exitCode := 0
+	for i, result := range results {
+		if opts.ExitBitmask && (result.invalid || result.failed) {
+			exitCode |= exitInvalid
+		} else if result.fatal {
+			return 1
+		} else if result.failed {
+			exitCode = 1
+		} else if result.changes != nil {
+			summary.Add(files[i], result.changes)
+			if opts.JSON == "changes" {
+				plans = append(plans, isort.NewPlan(result.changes))
+			}
+			if inventory != nil {
+				inventory.Add(files[i], result.changes)
+			}
+			io.WriteString(report, result.diff)
+			if (opts.Check != "" || opts.List) && result.changes.Needed {
+				fmt.Fprintln(report, files[i])
+			}
+			if opts.Report != "" && result.changes.Needed {
+				if err := writeDiagnostic(diagnostics, opts.Report, files[i], result.changes.StartLine); err != nil {
+					fmt.Fprintf(stderr, "Failed to write report: %s\n", err)
+					return 1
+				}
+			}
+			if (opts.Check != "" || opts.ExitBitmask || opts.Report != "") && result.changes.Needed {
+				exitCode |= exitChanged
+			}
+			if denied(files[i], result.changes, opts.Deny, stderr) {
+				if opts.ExitBitmask {
+					exitCode |= exitPolicy
+				} else {
+					exitCode = 1
+				}
+			}
+		}
+	}
+	if inventory != nil {
+		for _, warning := range inventory.DivergentAliases() {
+			fmt.Fprintf(stderr, "%s\n", warning)
+		}
+	}
+	if opts.JSON == "changes" {
+		if err := json.NewEncoder(report).Encode(plans); err != nil {
+			fmt.Fprintf(stderr, "Failed to write changes: %s\n", err)
+			return 1
+		}
+	} else if opts.JSON != "" && !opts.Write {
+		if err := json.NewEncoder(report).Encode(summary); err != nil {
+			fmt.Fprintf(stderr, "Failed to write summary: %s\n", err)
+			return 1
+		}
+	}
+	if opts.Summary {
+		if err := summary.WriteTree(report); err != nil {
+			fmt.Fprintf(stderr, "Failed to write summary: %s\n", err)
+			return 1
+		}
+	}
+	return exitCode
+}
+
+// formatStdin reformats source read from stdin and writes the result to stdout.
+func formatStdin(stdin io.Reader, stdout, stderr io.Writer, opts isort.Options) int {
+	src, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read stdin: %s\n", err)
+		return 1
+	}
+	out, _, err := isort.Format(src, "<stdin>", opts)
+	if _, ok := err.(*isort.ParseError); ok {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	} else if err != nil {
+		fmt.Fprintf(stderr, "Failed to parse <stdin>: %s\n", err)
+		return 1
+	} else if _, err := stdout.Write(out); err != nil {
+		fmt.Fprintf(stderr, "Failed to write output: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseGroupSet parses the argument to --groups into the equivalent Options.Groups.
+func parseGroupSet(set string) ([]string, error) {
+	switch set {
+	case "2":
+		return []string{isort.StdGroup + "+" + isort.ThirdPartyGroup, isort.LocalGroup}, nil
+	case "3":
+		return []string{isort.StdGroup, isort.ThirdPartyGroup, isort.LocalGroup}, nil
+	}
+	groups := strings.Split(set, ",")
+	for _, group := range groups {
+		for _, name := range strings.Split(group, "+") {
+			if name != isort.StdGroup && name != isort.ThirdPartyGroup && name != isort.LocalGroup {
+				return nil, fmt.Errorf("Invalid --groups %s: unknown group %s, must be one of std, thirdparty or local", set, name)
+			}
+		}
+	}
+	return groups, nil
+}
+
+// expandFiles expands any directories in the given arguments to all the Go files beneath them.
+// Directories can also be given in the form dir/... as with the go tool. Arguments that don't
+// exist but contain wildcards are expanded as globs, and must match something.
+// Anything matched by a .goisortignore file is skipped while traversing directories.
+func expandFiles(args []flags.Filename) ([]string, error) {
+	files := make([]string, 0, len(args))
+	for _, arg := range args {
+		root := string(arg)
+		if root == "..." {
+			root = "."
+		} else if strings.HasSuffix(root, "/...") {
+			root = strings.TrimSuffix(root, "/...")
+		}
+		if info, err := os.Stat(root); err != nil && hasGlobMeta(root) {
+			matches, err := expandGlob(root)
+			if err != nil {
+				return nil, err
+			} else if len(matches) == 0 {
+				return nil, fmt.Errorf("%s doesn't match any files", arg)
+			}
+			files = append(files, matches...)
+			continue
+		} else if err != nil || !info.IsDir() {
+			// Anything we can't find is reported when we try to process it.
+			files = append(files, string(arg))
+			continue
+		}
+		ignore, err := isort.FindIgnoreFile(root)
+		if err != nil {
+			return nil, err
+		}
+		if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			} else if d.Type()&fs.ModeSymlink != 0 {
+				return nil // Don't follow symlinks, they could lead to loops.
+			} else if d.IsDir() {
+				if name := d.Name(); path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || (ignore != nil && ignore.Match(path, true))) {
+					return filepath.SkipDir
+				}
+			} else if strings.HasSuffix(path, ".go") && (ignore == nil || !ignore.Match(path, false)) {
+				files = append(files, path)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// hasGlobMeta returns true if the given argument contains any glob metacharacters.
+func hasGlobMeta(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// expandGlob returns the Go files matching a glob pattern. As well as the usual filepath.Match
+// syntax, ** matches any number of directories (including none). Directories that match aren't
+// searched, so pkg/* is the same as pkg/*.go.
+func expandGlob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files := matches[:0]
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() && strings.HasSuffix(match, ".go") {
+				files = append(files, match)
+			}
+		}
+		return files, nil
+	}
+	// Walk from the deepest directory that doesn't contain any wildcards.
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(parts)-1 && !hasGlobMeta(parts[i]) {
+		i++
+	}
+	root := "."
+	if i == 1 && parts[0] == "" {
+		root = "/"
+	} else if i > 0 {
+		root = filepath.FromSlash(strings.Join(parts[:i], "/"))
+	}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var matches []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to parse %s: %s", filename, err)
-			os.Exit(1)
+			return err
+		} else if d.IsDir() || d.Type()&fs.ModeSymlink != 0 || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err == nil && matchGlob(parts[i:], strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
 		}
-		if opts.Write {
-			if err := isort.Rewrite(string(filename), string(filename), changes); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to rewrite %s: %s", filename, err)
-				os.Exit(1)
+		return err
+	})
+	return matches, err
+}
+
+// matchGlob returns true if the given path elements match those of a glob pattern.
+func matchGlob(pattern, elements []string) bool {
+	if len(pattern) == 0 {
+		return len(elements) == 0
+	} else if pattern[0] == "**" {
+		for i := range elements {
+			if matchGlob(pattern[1:], elements[i:]) {
+				return true
+			}
+		}
+		return matchGlob(pattern[1:], nil)
+	} else if len(elements) == 0 {
+		return false
+	}
+	matched, _ := filepath.Match(pattern[0], elements[0])
+	return matched && matchGlob(pattern[1:], elements[1:])
+}
+
+// Bits of the exit code with --exit-bitmask.
+const (
+	exitChanged = 1 << iota // Some files need changes, or were changed with --write.
+	exitInvalid             // Some files couldn't be read or parsed.
+	exitPolicy              // Some files import something given to --deny.
+)
+
+// denied reports any imports in the given file of paths given to --deny, and returns true if there were any.
+func denied(filename string, changes *isort.Changes, deny []string, stderr io.Writer) bool {
+	found := false
+	for _, imp := range changes.Imports {
+		path := strings.Trim(imp.Path, `"`)
+		for _, d := range deny {
+			if d = strings.TrimSuffix(d, "/"); path == d || strings.HasPrefix(path, d+"/") {
+				fmt.Fprintf(stderr, "%s:%d: import of %s is denied\n", filename, imp.Line, imp.Path)
+				found = true
+				break
 			}
 		}
 	}
+	return found
+}
+
+// A diagnostic is a single line of the --report=json output, in reviewdog's rdjsonl format
+// (see https://github.com/reviewdog/reviewdog/tree/master/proto/rdf).
+type diagnostic struct {
+	Message  string `json:"message"`
+	Location struct {
+		Path  string `json:"path"`
+		Range struct {
+			Start struct {
+				Line int `json:"line"`
+			} `json:"start"`
+		} `json:"range"`
+	} `json:"location"`
+	Severity string `json:"severity"`
+}
+
+// writeDiagnostic writes a diagnostic for a file whose imports need changes, in the given format.
+func writeDiagnostic(w io.Writer, format, filename string, line int) error {
+	const msg = "imports are not sorted"
+	if format == "text" {
+		_, err := fmt.Fprintf(w, "%s:%d: %s\n", filename, line, msg)
+		return err
+	}
+	d := diagnostic{Message: msg, Severity: "WARNING"}
+	d.Location.Path = filename
+	d.Location.Range.Start.Line = line
+	return json.NewEncoder(w).Encode(d)
+}
+
+// A result is the outcome of processing a single file.
+type result struct {
+	changes *isort.Changes // The changes made to the file, if it was processed successfully.
+	diff    string         // Diff of the changes, if requested.
+	failed  bool           // True if the file couldn't be processed, but others can be.
+	fatal   bool           // True if we should give up altogether.
+	invalid bool           // True if the file failed to parse.
+}
+
+// processFile reformats a single file, diffs it if diff is true, and rewrites it if write is true.
+// Any problems are reported to stderr as they happen.
+func processFile(filename string, opts isort.Options, cache *isort.Cache, write, diff bool, stderr io.Writer) result {
+	var contents []byte
+	if cache != nil {
+		b, err := ioutil.ReadFile(filename)
+		if msg := accessError(err); msg != "" {
+			// Problems finding the file don't stop us processing any others.
+			fmt.Fprintf(stderr, "%s: %s\n", filename, msg)
+			return result{failed: true}
+		} else if err != nil {
+			fmt.Fprintf(stderr, "Failed to read %s: %s\n", filename, err)
+			return result{fatal: true}
+		} else if cache.Sorted(filename, b, opts) {
+			return result{changes: &isort.Changes{}}
+		}
+		contents = b
+	}
+	changes, err := isort.Reformat(filename, opts)
+	if msg := accessError(err); msg != "" {
+		fmt.Fprintf(stderr, "%s: %s\n", filename, msg)
+		return result{failed: true}
+	} else if _, ok := err.(*isort.ParseError); ok {
+		fmt.Fprintf(stderr, "%s\n", err) // This already has the filename and position.
+		return result{fatal: true, invalid: true}
+	} else if err != nil {
+		fmt.Fprintf(stderr, "Failed to parse %s: %s\n", filename, err)
+		return result{fatal: true, invalid: true}
+	}
+	for _, warning := range changes.Warnings {
+		fmt.Fprintf(stderr, "%s:%d: %s\n", filename, warning.Line, warning.Message)
+	}
+	if cache != nil && !changes.Needed && len(changes.Warnings) == 0 {
+		// Files with warnings aren't cached, or they wouldn't be repeated next time.
+		if err := cache.MarkSorted(filename, contents, opts); err != nil {
+			fmt.Fprintf(stderr, "Failed to update cache for %s: %s\n", filename, err)
+		}
+	}
+	r := result{changes: changes}
+	if diff {
+		d, err := isort.Diff(filename, changes)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to diff %s: %s\n", filename, err)
+			return result{fatal: true}
+		}
+		r.diff = d
+	}
+	if write {
+		if err := isort.Rewrite(filename, filename, changes); err != nil {
+			fmt.Fprintf(stderr, "Failed to rewrite %s: %s\n", filename, err)
+			return result{fatal: true}
+		}
+	}
+	return r
+}
+
+// checkStable checks that reformatting a file settles down after a few runs, and reports it if not.
+// Anything stopping it being read or parsed is left for processFile to report.
+func checkStable(filename string, opts isort.Options, stderr io.Writer) bool {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return true
+	}
+	if _, _, err := isort.Format(b, filename, opts); err != nil {
+		return true // A parse error, not instability.
+	} else if err := isort.CheckStable(b, filename, opts); err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return false
+	}
+	return true
+}
+
+// A syncWriter serialises writes to an underlying writer.
+type syncWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (w *syncWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.w.Write(b)
+}
+
+// accessError returns a short description of err if it's a failure to find or open a file,
+// as opposed to one to parse it, or the empty string otherwise.
+func accessError(err error) string {
+	if os.IsNotExist(err) {
+		return "no such file"
+	} else if os.IsPermission(err) {
+		return "permission denied"
+	}
+	return ""
+}
+
+// createOutput creates the given output file, and any parent directories it needs.
+func createOutput(filename string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(filename)
 }
@@ -0,0 +1,647 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sortedFile = `package core
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+)
+`
+
+const unsortedFile = `package core
+
+import (
+	"github.com/jessevdk/go-flags"
+	"os"
+	"fmt"
+)
+`
+
+func TestOutput(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	sorted := writeFile(t, dir, "sorted.go", sortedFile)
+	unsorted := writeFile(t, dir, "unsorted.go", unsortedFile)
+	output := filepath.Join(dir, "reports", "goisort.json")
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--json", "--output", output, sorted, unsorted}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+	b, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"changed": 1, "total": 2, "files": ["`+unsorted+`"]}`, string(b))
+}
+
+const localFile = `package core
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"example.com/local/isort"
+)
+`
+
+func TestOnlyNeededFiles(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	sorted := writeFile(t, dir, "sorted.go", sortedFile)
+	unsorted := writeFile(t, dir, "unsorted.go", unsortedFile)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--json", sorted, unsorted, sorted}, nil, &stdout, &stderr))
+	assert.JSONEq(t, `{"changed": 1, "total": 3, "files": ["`+unsorted+`"]}`, stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--json", "--all", sorted, unsorted}, nil, &stdout, &stderr))
+	assert.JSONEq(t, `{"changed": 1, "total": 2, "files": ["`+sorted+`", "`+unsorted+`"]}`, stdout.String())
+}
+
+func TestNonexistentFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	missing := filepath.Join(dir, "missing.go")
+	unsorted := writeFile(t, dir, "unsorted.go", unsortedFile)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--json", missing, unsorted}, nil, &stdout, &stderr))
+	assert.Equal(t, missing+": no such file\n", stderr.String())
+	assert.JSONEq(t, `{"changed": 1, "total": 1, "files": ["`+unsorted+`"]}`, stdout.String())
+}
+
+func TestJobs(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	var files, unsorted []string
+	for i := 0; i < 20; i++ {
+		name := strconv.Itoa(i)
+		if i%3 == 0 {
+			files = append(files, writeFile(t, dir, "unsorted"+name+".go", unsortedFile))
+			unsorted = append(unsorted, files[len(files)-1])
+		} else {
+			files = append(files, writeFile(t, dir, "sorted"+name+".go", sortedFile))
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run(append([]string{"--jobs", "4", "--json"}, files...), nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	assert.JSONEq(t, `{"changed": 7, "total": 20, "files": ["`+strings.Join(unsorted, `", "`)+`"]}`, stdout.String())
+
+	missing := filepath.Join(dir, "missing.go")
+	stdout.Reset()
+	assert.Equal(t, 1, run(append([]string{"-j", "4", "-w", missing}, files...), nil, &stdout, &stderr))
+	assert.Equal(t, missing+": no such file\n", stderr.String())
+	for _, filename := range files {
+		b, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, sortedFile, string(b))
+	}
+}
+
+func TestInvalidJobs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--jobs", "0", "test.go"}, nil, &stdout, &stderr))
+	assert.Equal(t, "Invalid value for --jobs: 0\n", stderr.String())
+}
+
+func TestSummaryTree(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, dir, "a/sorted.go", sortedFile)
+	writeFile(t, dir, "a/unsorted.go", unsortedFile)
+	writeFile(t, dir, "a/b/unsorted.go", unsortedFile)
+	writeFile(t, dir, "c/sorted.go", sortedFile)
+	writeFile(t, dir, "README.md", "not go")
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--summary", dir}, nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, dir+`: 2/4
+  a: 2/3
+    b: 1/1
+  c: 0/1
+`, stdout.String())
+}
+
+func TestProfiles(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	unsorted := writeFile(t, dir, "unsorted.go", unsortedFile)
+	cpuProfile := filepath.Join(dir, "cpu.prof")
+	memProfile := filepath.Join(dir, "mem.prof")
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--cpuprofile", cpuProfile, "--memprofile", memProfile, unsorted}, nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	for _, filename := range []string{cpuProfile, memProfile} {
+		info, err := os.Stat(filename)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Size())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	sorted := writeFile(t, dir, "sorted.go", sortedFile)
+	unsorted := writeFile(t, dir, "unsorted.go", unsortedFile)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--check", sorted}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+
+	assert.Equal(t, 1, run([]string{"-c", sorted, unsorted}, nil, &stdout, &stderr))
+	assert.Equal(t, unsorted+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+	b, err := ioutil.ReadFile(unsorted)
+	require.NoError(t, err)
+	assert.Equal(t, unsortedFile, string(b))
+
+	// This is only wrong within the stdlib group.
+	grouped := writeFile(t, dir, "grouped.go", strings.Replace(sortedFile, "\t\"fmt\"\n\t\"os\"\n", "\t\"os\"\n\t\"fmt\"\n", 1))
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--check=groups", sorted, grouped}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, 1, run([]string{"--check=groups", grouped, unsorted}, nil, &stdout, &stderr))
+	assert.Equal(t, unsorted+"\n", stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, 1, run([]string{"--check", "-w", unsorted}, nil, &stdout, &stderr))
+	assert.Equal(t, "--check and --write can't be used together\n", stderr.String())
+}
+
+func TestTagsNeedPackages(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--tags", "foo", "."}, nil, &stdout, &stderr))
+	assert.Equal(t, "--tags can only be used with --packages\n", stderr.String())
+}
+
+func TestList(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	unsorted := writeFile(t, dir, "a/unsorted.go", unsortedFile)
+	writeFile(t, dir, "a/sorted.go", sortedFile)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--list", dir + "/..."}, nil, &stdout, &stderr))
+	assert.Equal(t, unsorted+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+	b, err := ioutil.ReadFile(unsorted)
+	require.NoError(t, err)
+	assert.Equal(t, unsortedFile, string(b))
+}
+
+func TestReport(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	unsorted := writeFile(t, dir, "a/unsorted.go", unsortedFile)
+	writeFile(t, dir, "a/sorted.go", sortedFile)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--report=text", dir + "/..."}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, unsorted+":4: imports are not sorted\n", stderr.String())
+
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"--report=json", dir + "/..."}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.JSONEq(t, `{"message": "imports are not sorted", "location": {"path": "`+unsorted+`", "range": {"start": {"line": 4}}}, "severity": "WARNING"}`, stderr.String())
+
+	// --output redirects the report too.
+	stderr.Reset()
+	output := filepath.Join(dir, "report.txt")
+	assert.Equal(t, 1, run([]string{"--report=text", "--output", output, dir + "/..."}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+	contents, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, unsorted+":4: imports are not sorted\n", string(contents))
+}
+
+func TestJSONChanges(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	sorted := writeFile(t, dir, "sorted.go", sortedFile)
+	unsorted := writeFile(t, dir, "unsorted.go", unsortedFile)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--json=changes", sorted, unsorted}, nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	imports := `[
+		{"path": "fmt", "group": 0},
+		{"path": "os", "group": 0},
+		{"path": "github.com/jessevdk/go-flags", "group": 1}
+	]`
+	assert.JSONEq(t, `[
+		{"filename": "`+sorted+`", "start_line": 4, "end_line": 7, "needed": false, "imports": `+imports+`},
+		{"filename": "`+unsorted+`", "start_line": 4, "end_line": 6, "needed": true, "imports": `+imports+`}
+	]`, stdout.String())
+	// Nothing gets written.
+	b, err := ioutil.ReadFile(unsorted)
+	require.NoError(t, err)
+	assert.Equal(t, unsortedFile, string(b))
+
+	stdout.Reset()
+	assert.Equal(t, 1, run([]string{"--json=changes", "--write", unsorted}, nil, &stdout, &stderr))
+	assert.Equal(t, "--json=changes can't be used with --write\n", stderr.String())
+}
+
+func TestDiff(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	sorted := writeFile(t, dir, "sorted.go", sortedFile)
+	unsorted := writeFile(t, dir, "unsorted.go", unsortedFile)
+	name := strings.TrimPrefix(filepath.ToSlash(unsorted), "/")
+	const diff = `@@ -1,7 +1,8 @@
+ package core
+ 
+ import (
+-	"github.com/jessevdk/go-flags"
+-	"os"
+ 	"fmt"
++	"os"
++
++	"github.com/jessevdk/go-flags"
+ )
+`
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"-d", sorted, unsorted}, nil, &stdout, &stderr))
+	assert.Equal(t, "--- a/"+name+"\n+++ b/"+name+"\n"+diff, stdout.String())
+	assert.Empty(t, stderr.String())
+	b, err := ioutil.ReadFile(unsorted)
+	require.NoError(t, err)
+	assert.Equal(t, unsortedFile, string(b))
+
+	// With -w it should still print the diff, but also write the file.
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--diff", "-w", unsorted}, nil, &stdout, &stderr))
+	assert.Equal(t, "--- a/"+name+"\n+++ b/"+name+"\n"+diff, stdout.String())
+	b, err = ioutil.ReadFile(unsorted)
+	require.NoError(t, err)
+	assert.Equal(t, sortedFile, string(b))
+}
+
+func TestDetectOscillation(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "unsorted.go", unsortedFile)
+	var stdout, stderr bytes.Buffer
+	// The file needs changes, but they settle after one run so it's only reported by --check.
+	assert.Equal(t, 1, run([]string{"--detect-oscillation", "--check", filename}, nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, filename+"\n", stdout.String())
+}
+
+func TestGroupSet(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "merged.go", "package core\n\nimport (\n\t\"fmt\"\n\t\"github.com/jessevdk/go-flags\"\n\t\"os\"\n)\n")
+	for _, arg := range []string{"--groups=2", "--groups=std+thirdparty,local"} {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 0, run([]string{"--check", arg, filename}, nil, &stdout, &stderr), arg)
+		assert.Empty(t, stdout.String())
+	}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--check", "--groups=3", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, filename+"\n", stdout.String())
+
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"--groups=std+vendor", filename}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "unknown group vendor")
+}
+
+func TestGroupSpacing(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "sorted.go", sortedFile)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--check", "--group-spacing=1", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"--check", "--group-spacing=2", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, filename+"\n", stdout.String())
+	assert.Equal(t, 0, run([]string{"--write", "--group-spacing=2", filename}, nil, &stdout, &stderr))
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(sortedFile, "\"os\"\n\n", "\"os\"\n\n\n", 1), string(b))
+
+	assert.Equal(t, 1, run([]string{"--group-spacing=0", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, "Invalid --group-spacing 0, must be at least 1\n", stderr.String())
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"--group-spacing=2", "--gofmt", filename}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "--group-spacing can't be more than 1 with --gofmt")
+}
+
+func TestExtraGroups(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "x.go", "package core\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/jessevdk/go-flags\"\n\t\"golang.org/x/sync/errgroup\"\n)\n")
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--check", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"--check", "--extra-groups", "golang.org/x/", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, filename+"\n", stdout.String())
+
+	assert.Equal(t, 1, run([]string{"--extra-groups", "golang.org/x/", "--group-rule", "fmt=std", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, "--extra-groups and --group-rule can't be used together\n", stderr.String())
+}
+
+func TestConfigFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "x.go", "package core\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/jessevdk/go-flags\"\n\t\"golang.org/x/sync/errgroup\"\n)\n")
+	writeFile(t, dir, ".goisort.yaml", "groups:\n  - std\n  - golang.org/x/\n  - default\n")
+	// The config is found relative to each file, not the working directory.
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--check", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, filename+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+	// A closer one takes precedence.
+	stdout.Reset()
+	other := writeFile(t, dir, "sub/y.go", "package core\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/jessevdk/go-flags\"\n\t\"golang.org/x/sync/errgroup\"\n)\n")
+	writeFile(t, dir, "sub/.goisort.yaml", "groups: [std, default]\n")
+	assert.Equal(t, 1, run([]string{"--check", filename, other}, nil, &stdout, &stderr))
+	assert.Equal(t, filename+"\n", stdout.String())
+	// Flags take precedence.
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--check", "--groups=3", filename}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+
+	writeFile(t, dir, ".goisort.yaml", "groups: std\n")
+	assert.Equal(t, 1, run([]string{"--check", filename}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Failed to read config: ")
+}
+
+func TestAlwaysParens(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "x.go", "package core\n\nimport \"fmt\"\n")
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--check", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"--check", "--always-parens", filename}, nil, &stdout, &stderr))
+	assert.Equal(t, filename+"\n", stdout.String())
+	assert.Equal(t, 0, run([]string{"-w", "--always-parens", filename}, nil, &stdout, &stderr))
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "package core\n\nimport (\n\t\"fmt\"\n)\n", string(b))
+}
+
+func TestExitBitmask(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	invalid := writeFile(t, dir, "a_invalid.go", "package core\n\nimport (\n")
+	unsorted := writeFile(t, dir, "b_unsorted.go", unsortedFile)
+	sorted := writeFile(t, dir, "c_sorted.go", sortedFile)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitChanged|exitInvalid, run([]string{"--exit-bitmask", invalid, unsorted, sorted}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), invalid+":3:10: expected ')', found 'EOF'")
+	assert.Equal(t, exitChanged, run([]string{"--exit-bitmask", unsorted, sorted}, nil, &stdout, &stderr))
+	assert.Equal(t, 0, run([]string{"--exit-bitmask", sorted}, nil, &stdout, &stderr))
+	// A file can both need changes and import something denied.
+	stderr.Reset()
+	assert.Equal(t, exitChanged|exitPolicy, run([]string{"--exit-bitmask", "--deny", "github.com/jessevdk", unsorted}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), unsorted+`:4: import of "github.com/jessevdk/go-flags" is denied`)
+	assert.Equal(t, exitPolicy, run([]string{"--exit-bitmask", "--deny", "os", sorted}, nil, &stdout, &stderr))
+	assert.Equal(t, 0, run([]string{"--exit-bitmask", "--deny", "github.com/jessevdk/go", sorted}, nil, &stdout, &stderr))
+	// Without it, parse errors are just a failure.
+	assert.Equal(t, 1, run([]string{invalid, unsorted, sorted}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"--deny", "os", sorted}, nil, &stdout, &stderr))
+}
+
+func TestGOROOT(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, dir, "goroot/src/net/newpkg/newpkg.go", "package newpkg\n")
+	src := writeFile(t, dir, "a.go", "package core\n\nimport (\n\t\"fmt\"\n\t\"net/newpkg\"\n)\n")
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--check", src}, nil, &stdout, &stderr))
+	assert.Equal(t, 0, run([]string{"--check", "--goroot", filepath.Join(dir, "goroot"), src}, nil, &stdout, &stderr))
+}
+
+func TestCacheKeepsWarnings(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	sorted := writeFile(t, dir, "sorted.go", sortedFile)
+	cache := filepath.Join(dir, "cache")
+	// Nothing uses the imports, so they're warned about on every run, not just the first.
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 0, run([]string{"--cache", cache, "--warn-unused", sorted}, nil, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), `import "os" appears to be unused`)
+	}
+}
+
+func TestStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"-"}, strings.NewReader(unsortedFile), &stdout, &stderr))
+	assert.Equal(t, sortedFile, stdout.String())
+	assert.Empty(t, stderr.String())
+
+	stdout.Reset()
+	assert.Equal(t, 1, run([]string{"-w", "-"}, strings.NewReader(unsortedFile), &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "can't be used with")
+
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"--list", "-"}, strings.NewReader(unsortedFile), &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "--list")
+
+	for _, flag := range []string{"--report=text", "--json", "--json=changes", "--deny=os"} {
+		stderr.Reset()
+		assert.Equal(t, 1, run([]string{flag, "-"}, strings.NewReader(unsortedFile), &stdout, &stderr))
+		assert.Empty(t, stdout.String())
+		assert.Contains(t, stderr.String(), "can't be used with")
+	}
+}
+
+func TestDirectories(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	files := []string{
+		writeFile(t, dir, "a.go", unsortedFile),
+		writeFile(t, dir, "sub/b.go", unsortedFile),
+		writeFile(t, dir, "sub/deeper/c.go", unsortedFile),
+	}
+	for _, name := range []string{"vendor/d.go", "sub/testdata/e.go", ".git/f.go", "sub/.hidden/g.go"} {
+		writeFile(t, dir, name, unsortedFile)
+	}
+	writeFile(t, dir, "README.md", unsortedFile)
+	// A symlink back up the tree would loop forever if it were followed.
+	require.NoError(t, os.Symlink(dir, filepath.Join(dir, "sub", "loop")))
+
+	for _, arg := range []string{dir, dir + "/..."} {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 1, run([]string{"--check", arg}, nil, &stdout, &stderr))
+		assert.Equal(t, strings.Join(files, "\n")+"\n", stdout.String())
+		assert.Empty(t, stderr.String())
+	}
+}
+
+func TestIgnoreFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a := writeFile(t, dir, "a.go", unsortedFile)
+	c := writeFile(t, dir, "sub/c.go", unsortedFile)
+	for _, name := range []string{"a.pb.go", "gen/b.go", "sub/gen/d.go"} {
+		writeFile(t, dir, name, unsortedFile)
+	}
+	writeFile(t, dir, ".goisortignore", "# Generated code\n*.pb.go\n/gen/\nsub/gen\n")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0755))
+
+	for _, arg := range []string{dir + "/...", filepath.Join(dir, "sub")} {
+		var stdout, stderr bytes.Buffer
+		expected := []string{a, c}
+		if arg != dir+"/..." {
+			expected = expected[1:]
+		}
+		assert.Equal(t, 1, run([]string{"--check", arg}, nil, &stdout, &stderr))
+		assert.Equal(t, strings.Join(expected, "\n")+"\n", stdout.String())
+		assert.Empty(t, stderr.String())
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a := writeFile(t, dir, "a.go", unsortedFile)
+	b := writeFile(t, dir, "b.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\n"+unsortedFile)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"--check", dir}, nil, &stdout, &stderr))
+	assert.Equal(t, a+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--check", b}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+
+	assert.Equal(t, 1, run([]string{"--check", "--include-generated", b}, nil, &stdout, &stderr))
+	assert.Equal(t, b+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+}
+
+func TestGlobs(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a := writeFile(t, dir, "pkg/a.go", unsortedFile)
+	b := writeFile(t, dir, "pkg/sub/b.go", unsortedFile)
+	c := writeFile(t, dir, "pkg/sub/deeper/c.go", unsortedFile)
+	writeFile(t, dir, "pkg/sub/c.txt", unsortedFile)
+	for pattern, expected := range map[string][]string{
+		"pkg/*.go":         {a},
+		"pkg/**/*.go":      {a, b, c},
+		"pkg/**/c.go":      {c},
+		"**/sub/*.go":      {b},
+		"pkg/*/*/[a-c].go": {c},
+		// Directories and files that aren't Go are left out.
+		"pkg/*":      {a},
+		"pkg/sub/*":  {b},
+		"pkg/**/*":   {a, b, c},
+		"pkg/**/c.*": {c},
+	} {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 1, run([]string{"--check", filepath.Join(dir, pattern)}, nil, &stdout, &stderr), pattern)
+		assert.Equal(t, strings.Join(expected, "\n")+"\n", stdout.String(), pattern)
+	}
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{filepath.Join(dir, "nothing/**/*.go")}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "doesn't match any files")
+}
+
+func TestLocalPackageFromEnv(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "local.go", localFile)
+
+	// Without the local package, example.com/local is just another third-party import.
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--json", filename}, nil, &stdout, &stderr))
+	assert.JSONEq(t, `{"changed": 1, "total": 1, "files": ["`+filename+`"]}`, stdout.String())
+
+	os.Setenv("GOISORT_LOCAL_PACKAGE", "example.com/local")
+	defer os.Unsetenv("GOISORT_LOCAL_PACKAGE")
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--json", filename}, nil, &stdout, &stderr))
+	assert.JSONEq(t, `{"changed": 0, "total": 1, "files": []}`, stdout.String())
+
+	// The flag takes precedence over the environment variable.
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--json", "--local_package", "example.com/other", filename}, nil, &stdout, &stderr))
+	assert.JSONEq(t, `{"changed": 1, "total": 1, "files": ["`+filename+`"]}`, stdout.String())
+}
+
+func TestLocalPackageFromGoMod(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, dir, "go.mod", "module example.com/local\n")
+	filename := writeFile(t, dir, "some/sub/dir/local.go", localFile)
+
+	// The module is found from the nearest go.mod, even though it's a few directories up.
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"--json", filename}, nil, &stdout, &stderr))
+	assert.JSONEq(t, `{"changed": 0, "total": 1, "files": []}`, stdout.String())
+
+	// An explicit flag overrides it.
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--json", "--local_package", "example.com/other", filename}, nil, &stdout, &stderr))
+	assert.JSONEq(t, `{"changed": 1, "total": 1, "files": ["`+filename+`"]}`, stdout.String())
+}
+
+func TestMultipleLocalPackages(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := writeFile(t, dir, "local.go", "package core\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/isort\"\n\t\"example.com/shared/log\"\n)\n")
+	for _, args := range [][]string{
+		{"-l", "example.com/app", "-l", "example.com/shared"},
+		{"--local_package", "example.com/app,example.com/shared"},
+	} {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 0, run(append(args, "--check", filename), nil, &stdout, &stderr), args)
+		assert.Empty(t, stdout.String())
+	}
+	// With only one of them, the other is third-party.
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, run([]string{"-l", "example.com/app", "--check", filename}, nil, &stdout, &stderr))
+}
+
+func TestServer(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader(`{"filename": "unsorted.go", "content": ` + strconv.Quote(unsortedFile) + `}` + "\n")
+	assert.Equal(t, 0, run([]string{"--server"}, stdin, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	assert.JSONEq(t, `{"content": `+strconv.Quote(sortedFile)+`, "changed": true}`, stdout.String())
+}
+
+// tempDir creates a new temporary directory for a test.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "goisort")
+	require.NoError(t, err)
+	return dir
+}
+
+// writeFile writes a file into the given directory and returns its path.
+func writeFile(t *testing.T, dir, name, contents string) string {
+	filename := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, ioutil.WriteFile(filename, []byte(contents), 0644))
+	return filename
+}